@@ -3,16 +3,22 @@ package main
 import (
 	"github.com/apex/log"
 	"github.com/ooni/probe-cli/internal/cli/app"
+	_ "github.com/ooni/probe-cli/internal/cli/bundle"
 	_ "github.com/ooni/probe-cli/internal/cli/geoip"
+	_ "github.com/ooni/probe-cli/internal/cli/importer"
 	_ "github.com/ooni/probe-cli/internal/cli/info"
 	_ "github.com/ooni/probe-cli/internal/cli/list"
 	_ "github.com/ooni/probe-cli/internal/cli/onboard"
+	_ "github.com/ooni/probe-cli/internal/cli/profile"
+	_ "github.com/ooni/probe-cli/internal/cli/publishers"
+	_ "github.com/ooni/probe-cli/internal/cli/reprocess"
 	_ "github.com/ooni/probe-cli/internal/cli/reset"
 	_ "github.com/ooni/probe-cli/internal/cli/rm"
 	_ "github.com/ooni/probe-cli/internal/cli/run"
 	_ "github.com/ooni/probe-cli/internal/cli/show"
 	_ "github.com/ooni/probe-cli/internal/cli/upload"
 	_ "github.com/ooni/probe-cli/internal/cli/version"
+	_ "github.com/ooni/probe-cli/internal/cli/watch"
 	"github.com/ooni/probe-cli/internal/crashreport"
 )
 
@@ -0,0 +1,124 @@
+// Package bundle reads and writes bundles. A bundle is a gzip-compressed
+// tar archive that we use to move runs and measurements between a machine
+// connected to the internet and an air-gapped probe.
+package bundle
+
+import (
+	"archive/tar"
+	"compress/gzip"
+	"io"
+	"os"
+	"path"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"github.com/pkg/errors"
+)
+
+// ErrInvalidName indicates that an entry of a bundle has a name that
+// would cause it to be extracted outside of the destination directory.
+var ErrInvalidName = errors.New("bundle: invalid entry name")
+
+// Write writes a bundle containing the given files. The key of the map is
+// the name of the file inside the bundle and the value is its path on disk.
+func Write(w io.Writer, files map[string]string) error {
+	var names []string
+	for name := range files {
+		names = append(names, name)
+	}
+	sort.Strings(names) // make bundles reproducible
+	zw := gzip.NewWriter(w)
+	tw := tar.NewWriter(zw)
+	for _, name := range names {
+		if err := addFile(tw, name, files[name]); err != nil {
+			return err
+		}
+	}
+	if err := tw.Close(); err != nil {
+		return err
+	}
+	return zw.Close()
+}
+
+func addFile(tw *tar.Writer, name, filename string) error {
+	if !isValidName(name) {
+		return ErrInvalidName
+	}
+	fp, err := os.Open(filename)
+	if err != nil {
+		return err
+	}
+	defer fp.Close()
+	info, err := fp.Stat()
+	if err != nil {
+		return err
+	}
+	header := &tar.Header{
+		Name:    name,
+		Mode:    0600,
+		Size:    info.Size(),
+		ModTime: info.ModTime(),
+	}
+	if err := tw.WriteHeader(header); err != nil {
+		return errors.Wrapf(err, "writing header for %s", name)
+	}
+	if _, err := io.Copy(tw, fp); err != nil {
+		return errors.Wrapf(err, "writing %s", name)
+	}
+	return nil
+}
+
+// Extract extracts the bundle read from r into dir and returns the
+// names of the extracted files.
+func Extract(r io.Reader, dir string) ([]string, error) {
+	zr, err := gzip.NewReader(r)
+	if err != nil {
+		return nil, errors.Wrap(err, "opening bundle")
+	}
+	defer zr.Close()
+	tr := tar.NewReader(zr)
+	var names []string
+	for {
+		header, err := tr.Next()
+		if err == io.EOF {
+			return names, nil
+		}
+		if err != nil {
+			return nil, errors.Wrap(err, "reading bundle")
+		}
+		if header.Typeflag != tar.TypeReg {
+			continue
+		}
+		if !isValidName(header.Name) {
+			return nil, ErrInvalidName
+		}
+		if err := extractFile(tr, filepath.Join(dir, filepath.FromSlash(header.Name))); err != nil {
+			return nil, err
+		}
+		names = append(names, header.Name)
+	}
+}
+
+func extractFile(r io.Reader, filename string) error {
+	if err := os.MkdirAll(filepath.Dir(filename), 0700); err != nil {
+		return err
+	}
+	fp, err := os.OpenFile(filename, os.O_CREATE|os.O_TRUNC|os.O_WRONLY, 0600)
+	if err != nil {
+		return err
+	}
+	if _, err := io.Copy(fp, r); err != nil {
+		fp.Close()
+		return errors.Wrapf(err, "extracting %s", filename)
+	}
+	return fp.Close()
+}
+
+func isValidName(name string) bool {
+	if name == "" || path.IsAbs(name) || strings.Contains(name, `\`) {
+		return false
+	}
+	cleaned := path.Clean(name)
+	return cleaned == name && cleaned != ".." && !strings.HasPrefix(cleaned, "../")
+}
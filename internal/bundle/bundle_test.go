@@ -0,0 +1,79 @@
+package bundle
+
+import (
+	"archive/tar"
+	"bytes"
+	"compress/gzip"
+	"errors"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestWriteAndExtract(t *testing.T) {
+	dir, err := ioutil.TempDir("", "ooniprobe-bundle")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+	source := filepath.Join(dir, "source.json")
+	if err := ioutil.WriteFile(source, []byte(`{"antani":1}`), 0600); err != nil {
+		t.Fatal(err)
+	}
+	var buf bytes.Buffer
+	err = Write(&buf, map[string]string{
+		"descriptor.json": source,
+		"assets/asn.mmdb": source,
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	dest := filepath.Join(dir, "dest")
+	names, err := Extract(&buf, dest)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(names) != 2 || names[0] != "assets/asn.mmdb" || names[1] != "descriptor.json" {
+		t.Fatalf("unexpected names: %+v", names)
+	}
+	data, err := ioutil.ReadFile(filepath.Join(dest, "assets", "asn.mmdb"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if string(data) != `{"antani":1}` {
+		t.Fatal("unexpected file content")
+	}
+}
+
+func TestWriteInvalidName(t *testing.T) {
+	var buf bytes.Buffer
+	err := Write(&buf, map[string]string{"../etc/passwd": "/etc/passwd"})
+	if !errors.Is(err, ErrInvalidName) {
+		t.Fatalf("not the error we expected: %+v", err)
+	}
+}
+
+func TestExtractInvalidName(t *testing.T) {
+	var buf bytes.Buffer
+	zw := gzip.NewWriter(&buf)
+	tw := tar.NewWriter(zw)
+	content := []byte("antani")
+	header := &tar.Header{Name: "../../evil", Mode: 0600, Size: int64(len(content))}
+	if err := tw.WriteHeader(header); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := tw.Write(content); err != nil {
+		t.Fatal(err)
+	}
+	tw.Close()
+	zw.Close()
+	dir, err := ioutil.TempDir("", "ooniprobe-bundle")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+	if _, err := Extract(&buf, dir); !errors.Is(err, ErrInvalidName) {
+		t.Fatalf("not the error we expected: %+v", err)
+	}
+}
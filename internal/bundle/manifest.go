@@ -0,0 +1,79 @@
+package bundle
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"io"
+	"os"
+	"path/filepath"
+
+	"github.com/pkg/errors"
+)
+
+// ManifestName is the name of the manifest inside a bundle.
+const ManifestName = "manifest.json"
+
+// ErrManifestMismatch indicates that the content of a bundle does not
+// match its manifest, i.e., the bundle is corrupted or was tampered with.
+var ErrManifestMismatch = errors.New("bundle: content does not match the manifest")
+
+// Manifest maps the name of each file inside a bundle to the hex
+// encoded SHA-256 digest of its content.
+type Manifest map[string]string
+
+// NewManifest computes the manifest of the given files. The files map
+// has the same meaning of the one passed to Write.
+func NewManifest(files map[string]string) (Manifest, error) {
+	m := make(Manifest)
+	for name, filename := range files {
+		digest, err := sha256File(filename)
+		if err != nil {
+			return nil, err
+		}
+		m[name] = digest
+	}
+	return m, nil
+}
+
+// Verify checks that the files with the given names, which have been
+// extracted into dir, are exactly the ones listed by the manifest. The
+// manifest itself, if present among names, is not checked.
+func (m Manifest) Verify(dir string, names []string) error {
+	seen := make(map[string]bool)
+	for _, name := range names {
+		if name == ManifestName {
+			continue
+		}
+		expected, found := m[name]
+		if !found {
+			return errors.Wrapf(ErrManifestMismatch, "unexpected file %s", name)
+		}
+		digest, err := sha256File(filepath.Join(dir, filepath.FromSlash(name)))
+		if err != nil {
+			return err
+		}
+		if digest != expected {
+			return errors.Wrapf(ErrManifestMismatch, "digest mismatch for %s", name)
+		}
+		seen[name] = true
+	}
+	for name := range m {
+		if !seen[name] {
+			return errors.Wrapf(ErrManifestMismatch, "missing file %s", name)
+		}
+	}
+	return nil
+}
+
+func sha256File(filename string) (string, error) {
+	fp, err := os.Open(filename)
+	if err != nil {
+		return "", err
+	}
+	defer fp.Close()
+	h := sha256.New()
+	if _, err := io.Copy(h, fp); err != nil {
+		return "", errors.Wrapf(err, "hashing %s", filename)
+	}
+	return hex.EncodeToString(h.Sum(nil)), nil
+}
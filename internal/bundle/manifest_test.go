@@ -0,0 +1,50 @@
+package bundle
+
+import (
+	"errors"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestManifestVerify(t *testing.T) {
+	dir, err := ioutil.TempDir("", "ooniprobe-bundle")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+	if err := os.Mkdir(filepath.Join(dir, "assets"), 0700); err != nil {
+		t.Fatal(err)
+	}
+	write := func(name, content string) {
+		path := filepath.Join(dir, filepath.FromSlash(name))
+		if err := ioutil.WriteFile(path, []byte(content), 0600); err != nil {
+			t.Fatal(err)
+		}
+	}
+	write("descriptor.json", `{"antani":1}`)
+	write("assets/asn.mmdb", "mascetti")
+	m, err := NewManifest(map[string]string{
+		"descriptor.json": filepath.Join(dir, "descriptor.json"),
+		"assets/asn.mmdb": filepath.Join(dir, "assets", "asn.mmdb"),
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	names := []string{"assets/asn.mmdb", "descriptor.json", ManifestName}
+	if err := m.Verify(dir, names); err != nil {
+		t.Fatal(err)
+	}
+	if err := m.Verify(dir, names[:1]); !errors.Is(err, ErrManifestMismatch) {
+		t.Fatalf("missing file: not the error we expected: %+v", err)
+	}
+	write("assets/country.mmdb", "sassaroli")
+	if err := m.Verify(dir, append(names, "assets/country.mmdb")); !errors.Is(err, ErrManifestMismatch) {
+		t.Fatalf("unexpected file: not the error we expected: %+v", err)
+	}
+	write("assets/asn.mmdb", "perozzi")
+	if err := m.Verify(dir, names); !errors.Is(err, ErrManifestMismatch) {
+		t.Fatalf("tampered file: not the error we expected: %+v", err)
+	}
+}
@@ -5,6 +5,7 @@ import (
 
 	"github.com/apex/log"
 	"github.com/ooni/probe-cli/internal/cli/root"
+	"github.com/ooni/probe-cli/internal/errcode"
 	"github.com/ooni/probe-cli/internal/version"
 )
 
@@ -13,7 +14,12 @@ func Run() {
 	root.Cmd.Version(version.Version)
 	_, err := root.Cmd.Parse(os.Args[1:])
 	if err != nil {
-		log.WithError(err).Error("failure in main command")
+		code := errcode.Of(err)
+		log.WithError(err).WithFields(log.Fields{
+			"error_code":       code,
+			"error_message":    code.Message().String(),
+			"error_message_id": code.Message().ID,
+		}).Error("failure in main command")
 		os.Exit(2)
 	}
 	return
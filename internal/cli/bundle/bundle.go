@@ -0,0 +1,277 @@
+package bundle
+
+import (
+	"crypto/ed25519"
+	"encoding/base64"
+	"encoding/json"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/alecthomas/kingpin"
+	"github.com/apex/log"
+	"github.com/fatih/color"
+	bundlefile "github.com/ooni/probe-cli/internal/bundle"
+	"github.com/ooni/probe-cli/internal/cli/root"
+	"github.com/ooni/probe-cli/internal/config"
+	"github.com/ooni/probe-cli/internal/database"
+	"github.com/ooni/probe-cli/internal/descriptor"
+	"github.com/ooni/probe-cli/internal/utils"
+	"github.com/ooni/probe-cli/internal/utils/shutil"
+	"github.com/pkg/errors"
+)
+
+const (
+	// descriptorName is the name of the descriptor inside a run bundle.
+	descriptorName = "descriptor.json"
+
+	// assetsPrefix is the prefix of the assets inside a run bundle.
+	assetsPrefix = "assets/"
+)
+
+// writeManifest writes the manifest of files into dir, signing it with
+// the given private key unless the key is nil, and adds the manifest
+// to files, such that import-run can verify the bundle.
+func writeManifest(dir string, files map[string]string, key ed25519.PrivateKey) error {
+	manifest, err := bundlefile.NewManifest(files)
+	if err != nil {
+		return errors.Wrap(err, "computing manifest")
+	}
+	data, err := json.Marshal(manifest)
+	if err != nil {
+		return err
+	}
+	if key != nil {
+		if data, err = json.Marshal(descriptor.Sign(data, key)); err != nil {
+			return err
+		}
+	}
+	path := filepath.Join(dir, bundlefile.ManifestName)
+	if err := ioutil.WriteFile(path, data, 0600); err != nil {
+		return err
+	}
+	files[bundlefile.ManifestName] = path
+	return nil
+}
+
+// readManifest reads the manifest at path. When the manifest is signed, we
+// verify the signature and we return the publisher if it is trusted. Like
+// for descriptors, the returned publisher is nil when the manifest is
+// unsigned or signed by an unknown publisher.
+func readManifest(path string, ts *descriptor.TrustStore) (bundlefile.Manifest, *descriptor.Publisher, error) {
+	data, err := ioutil.ReadFile(path)
+	if err != nil {
+		return nil, nil, errors.Wrap(err, "reading manifest")
+	}
+	var (
+		signed    descriptor.Signed
+		publisher *descriptor.Publisher
+	)
+	if err := json.Unmarshal(data, &signed); err == nil && signed.Payload != nil {
+		if publisher, err = ts.Publisher(&signed); err != nil {
+			return nil, nil, err
+		}
+		data = signed.Payload
+	}
+	var manifest bundlefile.Manifest
+	if err := json.Unmarshal(data, &manifest); err != nil {
+		return nil, nil, errors.Wrap(err, "parsing manifest")
+	}
+	return manifest, publisher, nil
+}
+
+// readPrivateKey reads the base64 encoded ed25519 private key at path.
+func readPrivateKey(path string) (ed25519.PrivateKey, error) {
+	data, err := ioutil.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	key, err := base64.StdEncoding.DecodeString(strings.TrimSpace(string(data)))
+	if err != nil {
+		return nil, errors.Wrap(err, "decoding private key")
+	}
+	if len(key) != ed25519.PrivateKeySize {
+		return nil, errors.New("invalid ed25519 private key")
+	}
+	return ed25519.PrivateKey(key), nil
+}
+
+func writeBundle(output string, files map[string]string) error {
+	fp, err := os.Create(output)
+	if err != nil {
+		return err
+	}
+	if err := bundlefile.Write(fp, files); err != nil {
+		fp.Close()
+		return err
+	}
+	return fp.Close()
+}
+
+func init() {
+	cmd := root.Command("bundle", "Move runs and results to and from air-gapped probes")
+
+	exportRunCmd := cmd.Command("export-run", "Export a run descriptor and the assets into a bundle")
+	exportRunDescriptor := exportRunCmd.Arg("descriptor", "Path to the run descriptor").Required().String()
+	exportRunOutput := exportRunCmd.Arg("output", "Path of the bundle to write").Required().String()
+	exportRunKeyFile := exportRunCmd.Flag("key-file", "File containing the base64 encoded ed25519 private key used to sign the bundle").String()
+	exportRunCmd.Action(func(_ *kingpin.ParseContext) error {
+		probe, err := root.Init()
+		if err != nil {
+			log.WithError(err).Error("failed to initialize root context")
+			return err
+		}
+		var key ed25519.PrivateKey
+		if *exportRunKeyFile != "" {
+			if key, err = readPrivateKey(*exportRunKeyFile); err != nil {
+				log.WithError(err).Error("failed to read the private key")
+				return err
+			}
+		} else {
+			log.Warn("the bundle is not signed and will only be imported by probes accepting untrusted run descriptors")
+		}
+		if _, err := descriptor.Read(*exportRunDescriptor); err != nil {
+			log.WithError(err).Error("failed to read the run descriptor")
+			return err
+		}
+		files := map[string]string{descriptorName: *exportRunDescriptor}
+		assetsDir := utils.AssetsDir(probe.Home())
+		infos, err := ioutil.ReadDir(assetsDir)
+		if err != nil {
+			return errors.Wrap(err, "listing assets")
+		}
+		for _, info := range infos {
+			if info.Mode().IsRegular() {
+				files[assetsPrefix+info.Name()] = filepath.Join(assetsDir, info.Name())
+			}
+		}
+		tempDir, err := ioutil.TempDir(probe.TempDir(), "bundle")
+		if err != nil {
+			return err
+		}
+		defer os.RemoveAll(tempDir)
+		if err := writeManifest(tempDir, files, key); err != nil {
+			log.WithError(err).Error("failed to write the manifest")
+			return err
+		}
+		if err := writeBundle(*exportRunOutput, files); err != nil {
+			log.WithError(err).Error("failed to write the bundle")
+			return err
+		}
+		log.Infof("Written run bundle to %s", *exportRunOutput)
+		return nil
+	})
+
+	importRunCmd := cmd.Command("import-run", "Import a run bundle created with export-run")
+	importRunBundle := importRunCmd.Arg("bundle", "Path to the bundle").Required().String()
+	importRunCmd.Action(func(_ *kingpin.ParseContext) error {
+		probe, err := root.Init()
+		if err != nil {
+			log.WithError(err).Error("failed to initialize root context")
+			return err
+		}
+		fp, err := os.Open(*importRunBundle)
+		if err != nil {
+			return err
+		}
+		defer fp.Close()
+		tempDir, err := ioutil.TempDir(probe.TempDir(), "bundle")
+		if err != nil {
+			return err
+		}
+		defer os.RemoveAll(tempDir)
+		names, err := bundlefile.Extract(fp, tempDir)
+		if err != nil {
+			log.WithError(err).Error("failed to extract the bundle")
+			return err
+		}
+		// Verify the whole bundle before touching the assets, which are
+		// used by every nettest and not only by the imported descriptor.
+		kvstore, err := probe.NewKVStore()
+		if err != nil {
+			return err
+		}
+		manifest, publisher, err := readManifest(
+			filepath.Join(tempDir, bundlefile.ManifestName), descriptor.NewTrustStore(kvstore))
+		if err != nil {
+			log.WithError(err).Error("failed to verify the bundle")
+			return err
+		}
+		if publisher == nil {
+			if probe.Config().Advanced.UntrustedDescriptorPolicy != config.DescriptorPolicyWarn {
+				log.Error("refusing to import unsigned or untrusted run bundle")
+				return errors.New("untrusted run bundle")
+			}
+			log.Warn("importing unsigned or untrusted run bundle")
+		} else {
+			log.Infof("Run bundle signed by %s", color.BlueString(publisher.Name))
+		}
+		if err := manifest.Verify(tempDir, names); err != nil {
+			log.WithError(err).Error("failed to verify the bundle")
+			return err
+		}
+		descriptorsDir := utils.DescriptorsDir(probe.Home())
+		if err := os.MkdirAll(descriptorsDir, 0700); err != nil {
+			return err
+		}
+		for _, name := range names {
+			source := filepath.Join(tempDir, filepath.FromSlash(name))
+			var dest string
+			switch {
+			case name == bundlefile.ManifestName:
+				continue
+			case name == descriptorName:
+				base := strings.TrimSuffix(filepath.Base(*importRunBundle), ".tar.gz")
+				dest = filepath.Join(descriptorsDir, base+".json")
+			case strings.HasPrefix(name, assetsPrefix) && !strings.Contains(name[len(assetsPrefix):], "/"):
+				dest = filepath.Join(utils.AssetsDir(probe.Home()), name[len(assetsPrefix):])
+			default:
+				log.Warnf("Ignoring unexpected bundle entry: %s", name)
+				continue
+			}
+			if err := shutil.CopyFile(source, dest, false); err != nil {
+				return errors.Wrapf(err, "importing %s", name)
+			}
+			log.Infof("Imported %s", dest)
+			if name == descriptorName {
+				log.Infof("Run it with `ooniprobe run descriptor %s`",
+					strings.TrimSuffix(filepath.Base(dest), ".json"))
+			}
+		}
+		return nil
+	})
+
+	exportResultCmd := cmd.Command("export-result", "Export the measurements of a result into a bundle")
+	exportResultID := exportResultCmd.Arg("id", "the id of the result to export").Required().Int64()
+	exportResultOutput := exportResultCmd.Arg("output", "Path of the bundle to write").Required().String()
+	exportResultCmd.Action(func(_ *kingpin.ParseContext) error {
+		probe, err := root.Init()
+		if err != nil {
+			log.WithError(err).Error("failed to initialize root context")
+			return err
+		}
+		measurements, err := database.ListMeasurements(probe.DB(), *exportResultID)
+		if err != nil {
+			log.WithError(err).Error("failed to list measurements")
+			return err
+		}
+		files := make(map[string]string)
+		for _, msmt := range measurements {
+			if !msmt.Measurement.IsDone || !msmt.MeasurementFilePath.Valid {
+				continue
+			}
+			path := msmt.MeasurementFilePath.String
+			files[filepath.Base(path)] = path
+		}
+		if len(files) <= 0 {
+			return errors.New("no measurements to export")
+		}
+		if err := writeBundle(*exportResultOutput, files); err != nil {
+			log.WithError(err).Error("failed to write the bundle")
+			return err
+		}
+		log.Infof("Written %d measurements to %s", len(files), *exportResultOutput)
+		return nil
+	})
+}
@@ -4,6 +4,7 @@ import (
 	"github.com/alecthomas/kingpin"
 	"github.com/apex/log"
 	"github.com/ooni/probe-cli/internal/cli/root"
+	"github.com/ooni/probe-cli/internal/errcode"
 	"github.com/ooni/probe-cli/internal/ooni"
 	"github.com/ooni/probe-cli/internal/output"
 )
@@ -42,7 +43,7 @@ func dogeoip(config dogeoipconfig) error {
 
 	err = engine.MaybeLookupLocation()
 	if err != nil {
-		return err
+		return errcode.New(errcode.GeolocationFailed, err)
 	}
 
 	config.Logger.WithFields(log.Fields{
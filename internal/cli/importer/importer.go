@@ -0,0 +1,27 @@
+package importer
+
+import (
+	"github.com/alecthomas/kingpin"
+	"github.com/apex/log"
+	"github.com/ooni/probe-cli/internal/cli/root"
+	"github.com/ooni/probe-cli/internal/nettests"
+)
+
+func init() {
+	cmd := root.Command("import", "Import published measurements from the OONI API")
+	reportIDs := cmd.Arg("report-id", "the IDs of the reports to import").Required().Strings()
+	cmd.Action(func(_ *kingpin.ParseContext) error {
+		probe, err := root.Init()
+		if err != nil {
+			log.WithError(err).Error("failed to initialize root context")
+			return err
+		}
+		count, err := nettests.Import(probe, *reportIDs)
+		if err != nil {
+			log.WithError(err).Errorf("failed to import measurements (imported %d before failing)", count)
+			return err
+		}
+		log.Infof("Imported %d measurements", count)
+		return nil
+	})
+}
@@ -122,6 +122,15 @@ func init() {
 			}
 			resultSummary.TotalNetworks = int64(len(netCount))
 			output.ResultSummary(resultSummary)
+			backlog, err := database.GetUploadBacklog(probeCLI.DB())
+			if err != nil {
+				log.WithError(err).Error("failed to get the upload backlog")
+				return err
+			}
+			output.UploadBacklog(output.UploadBacklogData{
+				Count:  backlog.Count,
+				Oldest: backlog.Oldest,
+			})
 		}
 		return nil
 	})
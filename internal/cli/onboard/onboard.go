@@ -2,12 +2,14 @@ package onboard
 
 import (
 	"fmt"
+	"strings"
 
 	"github.com/alecthomas/kingpin"
 	"github.com/apex/log"
 	"github.com/fatih/color"
 	"github.com/ooni/probe-cli/internal/cli/root"
 	"github.com/ooni/probe-cli/internal/config"
+	"github.com/ooni/probe-cli/internal/i18n"
 	"github.com/ooni/probe-cli/internal/ooni"
 	"github.com/ooni/probe-cli/internal/output"
 	"github.com/pkg/errors"
@@ -90,11 +92,13 @@ func Onboarding(config *config.Config) error {
 		IncludeNetwork   bool
 		UploadResults    bool
 		SendCrashReports bool
+		TestSensitive    bool
 	}{}
 	settings.IncludeIP = false
 	settings.IncludeNetwork = true
 	settings.UploadResults = true
 	settings.SendCrashReports = true
+	settings.TestSensitive = false
 
 	if changeDefaults == true {
 		var qs = []*survey.Question{
@@ -123,6 +127,13 @@ func Onboarding(config *config.Config) error {
 					Default: true,
 				},
 			},
+			{
+				Name: "TestSensitive",
+				Prompt: &survey.Confirm{
+					Message: sensitiveCategoriesPrompt().String(),
+					Default: false,
+				},
+			},
 		}
 
 		if err := survey.Ask(qs, &settings); err != nil {
@@ -133,8 +144,13 @@ func Onboarding(config *config.Config) error {
 
 	config.Lock()
 	config.InformedConsent = true
+	if name, profile, found := config.ActiveProfile(); found {
+		profile.InformedConsent = true
+		config.Profiles[name] = profile
+	}
 	config.Advanced.SendCrashReports = settings.SendCrashReports
 	config.Sharing.UploadResults = settings.UploadResults
+	config.Nettests.WebsitesTestSensitive = settings.TestSensitive
 	config.Unlock()
 
 	if err := config.Write(); err != nil {
@@ -144,10 +160,20 @@ func Onboarding(config *config.Config) error {
 	return nil
 }
 
+// sensitiveCategoriesPrompt returns the message asking the user
+// whether to test sensitive website categories.
+func sensitiveCategoriesPrompt() i18n.Message {
+	codes := config.DefaultRiskProfile().SensitiveCategoryCodes
+	return i18n.New(
+		"onboard.test_sensitive_categories",
+		"categories", strings.Join(codes, ", "),
+	)
+}
+
 // MaybeOnboarding will run the onboarding process only if the informed consent
 // config option is set to false
 func MaybeOnboarding(probe *ooni.Probe) error {
-	if probe.Config().InformedConsent == false {
+	if probe.Config().HasInformedConsent() == false {
 		if probe.IsBatch() == true {
 			return errors.New("cannot run onboarding in batch mode")
 		}
@@ -172,6 +198,10 @@ func init() {
 		if *yes == true {
 			probe.Config().Lock()
 			probe.Config().InformedConsent = true
+			if name, profile, found := probe.Config().ActiveProfile(); found {
+				profile.InformedConsent = true
+				probe.Config().Profiles[name] = profile
+			}
 			probe.Config().Unlock()
 
 			if err := probe.Config().Write(); err != nil {
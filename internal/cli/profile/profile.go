@@ -0,0 +1,114 @@
+package profile
+
+import (
+	"strings"
+
+	"github.com/alecthomas/kingpin"
+	"github.com/apex/log"
+	"github.com/ooni/probe-cli/internal/cli/root"
+	"github.com/ooni/probe-cli/internal/config"
+	"github.com/ooni/probe-cli/internal/ooni"
+	"github.com/pkg/errors"
+)
+
+// updateConfig runs the given function with the config locked and then
+// writes the config back to disk.
+func updateConfig(probe *ooni.Probe, fn func(c *config.Config) error) error {
+	c := probe.Config()
+	c.Lock()
+	err := fn(c)
+	c.Unlock()
+	if err != nil {
+		return err
+	}
+	return c.Write()
+}
+
+func init() {
+	cmd := root.Command("profile", "Manage the named profiles (e.g. home and travel)")
+
+	var probe *ooni.Probe
+	cmd.Action(func(_ *kingpin.ParseContext) error {
+		var err error
+		probe, err = root.Init()
+		if err != nil {
+			log.WithError(err).Error("failed to initialize root context")
+			return err
+		}
+		return nil
+	})
+
+	listCmd := cmd.Command("list", "List the profiles").Default()
+	listCmd.Action(func(_ *kingpin.ParseContext) error {
+		active, _, _ := probe.Config().ActiveProfile()
+		for name, p := range probe.Config().Profiles {
+			log.WithFields(log.Fields{
+				"type":             "table",
+				"name":             name,
+				"active":           name == active,
+				"informed_consent": p.InformedConsent,
+				"categories":       strings.Join(p.WebsitesEnabledCategoryCodes, ","),
+			}).Info("profile")
+		}
+		return nil
+	})
+
+	addCmd := cmd.Command("add", "Add or replace a profile")
+	addName := addCmd.Arg("name", "the name of the profile").Required().String()
+	addCategories := addCmd.Flag("category", "Enable the given website category code").Strings()
+	addAnnotations := addCmd.Flag("annotation", "Add the given annotation to measurements").StringMap()
+	addCmd.Action(func(_ *kingpin.ParseContext) error {
+		err := updateConfig(probe, func(c *config.Config) error {
+			if c.Profiles == nil {
+				c.Profiles = make(map[string]config.Profile)
+			}
+			// Replacing a profile means the user must consent again.
+			c.Profiles[*addName] = config.Profile{
+				WebsitesEnabledCategoryCodes: *addCategories,
+				Annotations:                  *addAnnotations,
+			}
+			return nil
+		})
+		if err != nil {
+			log.WithError(err).Error("failed to add profile")
+			return err
+		}
+		log.Infof("Added profile %s", *addName)
+		return nil
+	})
+
+	useCmd := cmd.Command("use", "Activate a profile (an empty name deactivates it)")
+	useName := useCmd.Arg("name", "the name of the profile").String()
+	useCmd.Action(func(_ *kingpin.ParseContext) error {
+		err := updateConfig(probe, func(c *config.Config) error {
+			return c.UseProfile(*useName)
+		})
+		if err != nil {
+			log.WithError(err).Error("failed to use profile")
+			return err
+		}
+		log.Infof("Active profile: %s", *useName)
+		return nil
+	})
+
+	rmCmd := cmd.Command("rm", "Remove a profile")
+	rmName := rmCmd.Arg("name", "the name of the profile").Required().String()
+	rmCmd.Action(func(_ *kingpin.ParseContext) error {
+		err := updateConfig(probe, func(c *config.Config) error {
+			if _, found := c.Profiles[*rmName]; !found {
+				return errors.Errorf("no such profile: %s", *rmName)
+			}
+			delete(c.Profiles, *rmName)
+			if c.ActiveProfileName == *rmName {
+				c.ActiveProfileName = ""
+			}
+			return nil
+		})
+		if err != nil {
+			log.WithError(err).Error("failed to remove profile")
+			return err
+		}
+		log.Infof("Removed profile %s", *rmName)
+		return nil
+	})
+}
@@ -0,0 +1,84 @@
+package publishers
+
+import (
+	"encoding/base64"
+
+	"github.com/alecthomas/kingpin"
+	"github.com/apex/log"
+	"github.com/ooni/probe-cli/internal/cli/root"
+	"github.com/ooni/probe-cli/internal/descriptor"
+	"github.com/pkg/errors"
+)
+
+func newTrustStore() (*descriptor.TrustStore, error) {
+	probe, err := root.Init()
+	if err != nil {
+		log.WithError(err).Error("failed to initialize root context")
+		return nil, err
+	}
+	kvstore, err := probe.NewKVStore()
+	if err != nil {
+		return nil, err
+	}
+	return descriptor.NewTrustStore(kvstore), nil
+}
+
+func init() {
+	cmd := root.Command("publishers", "Manage the trusted publishers of run descriptors")
+
+	listCmd := cmd.Command("list", "List the trusted publishers").Default()
+	listCmd.Action(func(_ *kingpin.ParseContext) error {
+		ts, err := newTrustStore()
+		if err != nil {
+			return err
+		}
+		publishers, err := ts.List()
+		if err != nil {
+			log.WithError(err).Error("failed to list trusted publishers")
+			return err
+		}
+		for _, p := range publishers {
+			log.WithFields(log.Fields{
+				"type":       "table",
+				"name":       p.Name,
+				"public_key": base64.StdEncoding.EncodeToString(p.PublicKey),
+			}).Info("trusted publisher")
+		}
+		return nil
+	})
+
+	addCmd := cmd.Command("add", "Trust a publisher")
+	addName := addCmd.Arg("name", "the name of the publisher").Required().String()
+	addKey := addCmd.Arg("key", "the base64 encoded ed25519 public key").Required().String()
+	addCmd.Action(func(_ *kingpin.ParseContext) error {
+		publicKey, err := base64.StdEncoding.DecodeString(*addKey)
+		if err != nil {
+			return errors.Wrap(err, "decoding public key")
+		}
+		ts, err := newTrustStore()
+		if err != nil {
+			return err
+		}
+		if err := ts.Add(*addName, publicKey); err != nil {
+			log.WithError(err).Error("failed to add trusted publisher")
+			return err
+		}
+		log.Infof("Added %s to the trusted publishers", *addName)
+		return nil
+	})
+
+	rmCmd := cmd.Command("rm", "Stop trusting a publisher")
+	rmName := rmCmd.Arg("name", "the name of the publisher").Required().String()
+	rmCmd.Action(func(_ *kingpin.ParseContext) error {
+		ts, err := newTrustStore()
+		if err != nil {
+			return err
+		}
+		if err := ts.Remove(*rmName); err != nil {
+			log.WithError(err).Error("failed to remove trusted publisher")
+			return err
+		}
+		log.Infof("Removed %s from the trusted publishers", *rmName)
+		return nil
+	})
+}
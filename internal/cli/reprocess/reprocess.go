@@ -0,0 +1,27 @@
+package reprocess
+
+import (
+	"github.com/alecthomas/kingpin"
+	"github.com/apex/log"
+	"github.com/ooni/probe-cli/internal/cli/root"
+	"github.com/ooni/probe-cli/internal/nettests"
+)
+
+func init() {
+	cmd := root.Command("reprocess", "Recompute the anomaly verdicts of stored measurements")
+	resultID := cmd.Arg("id", "the id of the result to reprocess (default: all results)").Int64()
+	cmd.Action(func(_ *kingpin.ParseContext) error {
+		probe, err := root.Init()
+		if err != nil {
+			log.WithError(err).Error("failed to initialize root context")
+			return err
+		}
+		count, err := nettests.Reprocess(probe, *resultID)
+		if err != nil {
+			log.WithError(err).Error("failed to reprocess measurements")
+			return err
+		}
+		log.Infof("Reprocessed %d measurements", count)
+		return nil
+	})
+}
@@ -1,18 +1,90 @@
 package run
 
 import (
+	"context"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
 	"github.com/alecthomas/kingpin"
 	"github.com/apex/log"
 	"github.com/fatih/color"
 	"github.com/ooni/probe-cli/internal/cli/onboard"
 	"github.com/ooni/probe-cli/internal/cli/root"
+	"github.com/ooni/probe-cli/internal/config"
+	"github.com/ooni/probe-cli/internal/database"
+	"github.com/ooni/probe-cli/internal/descriptor"
+	"github.com/ooni/probe-cli/internal/errcode"
 	"github.com/ooni/probe-cli/internal/nettests"
 	"github.com/ooni/probe-cli/internal/ooni"
+	"github.com/ooni/probe-cli/internal/utils"
+	"github.com/pkg/errors"
 )
 
+// logEstimate logs the estimated cost of running the given group, so
+// that users on metered connections know what to expect.
+func logEstimate(probe *ooni.Probe, groupName string) {
+	estimate, err := database.EstimateResult(probe.DB(), groupName)
+	if err != nil {
+		log.WithError(err).Debug("cannot estimate the cost of the run")
+		return
+	}
+	if estimate.SampleSize <= 0 {
+		return
+	}
+	log.WithFields(log.Fields{
+		"type":            "estimate",
+		"group":           groupName,
+		"data_usage_up":   estimate.DataUsageUp,
+		"data_usage_down": estimate.DataUsageDown,
+		"runtime":         estimate.Runtime,
+		"sample_size":     estimate.SampleSize,
+	}).Infof("Estimated cost: %.0f KiB and %.0f seconds",
+		estimate.DataUsageUp+estimate.DataUsageDown, estimate.Runtime)
+}
+
+// descriptorPath returns the path of the run descriptor named by arg, which
+// is either a path or the name of a descriptor imported into the descriptors
+// directory by `bundle import-run`, with or without the .json extension.
+func descriptorPath(home, arg string) string {
+	if _, err := os.Stat(arg); err == nil {
+		return arg
+	}
+	for _, name := range []string{arg, arg + ".json"} {
+		path := filepath.Join(utils.DescriptorsDir(home), name)
+		if _, err := os.Stat(path); err == nil {
+			return path
+		}
+	}
+	return arg
+}
+
+// fetchDescriptor fetches the run descriptor at URL using the session's HTTP
+// client, which honours the configured proxy. Fetching is interrupted
+// when the probe is terminated.
+func fetchDescriptor(probe *ooni.Probe, URL string, ts *descriptor.TrustStore) (*descriptor.Descriptor, *descriptor.Publisher, error) {
+	sess, err := probe.NewSession()
+	if err != nil {
+		return nil, nil, err
+	}
+	defer sess.Close()
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	go func() {
+		select {
+		case <-probe.Terminated():
+			cancel()
+		case <-ctx.Done():
+		}
+	}()
+	return descriptor.Fetch(ctx, sess.DefaultHTTPClient(), URL, ts)
+}
+
 func init() {
 	cmd := root.Command("run", "Run a test group or OONI Run link")
 	noCollector := cmd.Flag("no-collector", "Disable uploading measurements to a collector").Bool()
+	profileName := cmd.Flag("profile", "Use the specified profile for this run").String()
 
 	var probe *ooni.Probe
 	cmd.Action(func(_ *kingpin.ParseContext) error {
@@ -22,6 +94,12 @@ func init() {
 			log.Errorf("%s", err)
 			return err
 		}
+		if *profileName != "" {
+			if err = probe.Config().UseProfile(*profileName); err != nil {
+				log.WithError(err).Error("failed to use profile")
+				return err
+			}
+		}
 		if err = onboard.MaybeOnboarding(probe); err != nil {
 			log.WithError(err).Error("failed to perform onboarding")
 			return err
@@ -38,9 +116,11 @@ func init() {
 				continue
 			}
 			log.Infof("Running %s tests", color.BlueString(name))
+			logEstimate(probe, name)
 			conf := nettests.RunGroupConfig{GroupName: name, Probe: probe}
 			if err := nettests.RunGroup(conf); err != nil {
-				log.WithError(err).Errorf("failed to run %s", name)
+				log.WithError(err).WithField(
+					"error_code", errcode.Of(err)).Errorf("failed to run %s", name)
 			}
 		}
 		return nil
@@ -59,6 +139,7 @@ func init() {
 	input := websitesCmd.Flag("input", "Test the specified URL").Strings()
 	websitesCmd.Action(func(_ *kingpin.ParseContext) error {
 		log.Infof("Running %s tests", color.BlueString("websites"))
+		logEstimate(probe, "websites")
 		return nettests.RunGroup(nettests.RunGroupConfig{
 			GroupName:  "websites",
 			Probe:      probe,
@@ -67,6 +148,58 @@ func init() {
 		})
 	})
 
+	descriptorCmd := cmd.Command("descriptor", "Run the nettests described by a run descriptor")
+	descriptorFile := descriptorCmd.Arg("file", "Path, imported name or HTTPS URL of the run descriptor").Required().String()
+	descriptorCmd.Action(func(_ *kingpin.ParseContext) error {
+		kvstore, err := probe.NewKVStore()
+		if err != nil {
+			return err
+		}
+		ts := descriptor.NewTrustStore(kvstore)
+		var (
+			d         *descriptor.Descriptor
+			publisher *descriptor.Publisher
+		)
+		probe.ListenForSignals()
+		if strings.HasPrefix(*descriptorFile, "https://") {
+			d, publisher, err = fetchDescriptor(probe, *descriptorFile, ts)
+		} else {
+			d, publisher, err = descriptor.Open(descriptorPath(probe.Home(), *descriptorFile), ts)
+		}
+		if err != nil {
+			log.WithError(err).Error("failed to read the run descriptor")
+			return err
+		}
+		if publisher == nil {
+			if probe.Config().Advanced.UntrustedDescriptorPolicy != config.DescriptorPolicyWarn {
+				log.Error("refusing to run unsigned or untrusted run descriptor")
+				return errors.New("untrusted run descriptor")
+			}
+			log.Warn("running unsigned or untrusted run descriptor")
+		} else {
+			log.Infof("Run descriptor signed by %s", color.BlueString(publisher.Name))
+		}
+		delay, err := d.Delay(time.Now())
+		if err != nil {
+			log.WithError(err).Error("refusing to run the run descriptor")
+			return err
+		}
+		if delay > 0 {
+			log.Infof("Waiting %s for the measurement window to begin", delay.Round(time.Second))
+			select {
+			case <-probe.Terminated():
+				log.Info("Interrupted while waiting for the measurement window")
+				return nil
+			case <-time.After(delay):
+			}
+		}
+		log.Infof("Running %s", color.BlueString(d.Name))
+		return nettests.RunGroup(nettests.RunGroupConfig{
+			Probe:      probe,
+			Descriptor: d,
+		})
+	})
+
 	easyRuns := []string{"im", "performance", "circumvention", "middlebox"}
 	for _, name := range easyRuns {
 		cmd.Command(name, "").Action(genRunWithGroupName(name))
@@ -1,17 +1,225 @@
 package upload
 
 import (
+	"context"
+	"encoding/json"
+	"io"
+	"io/ioutil"
+	"os"
+	"os/signal"
+	"path/filepath"
+	"strings"
+	"syscall"
+	"time"
+
 	"github.com/alecthomas/kingpin"
 	"github.com/apex/log"
+	"github.com/ooni/probe-cli/internal/bundle"
 	"github.com/ooni/probe-cli/internal/cli/root"
+	"github.com/ooni/probe-cli/internal/msmtstream"
+	"github.com/ooni/probe-cli/internal/msmttrim"
+	"github.com/ooni/probe-engine/model"
+	"github.com/ooni/probe-engine/probeservices"
+	"github.com/pkg/errors"
 )
 
 func init() {
-	cmd := root.Command("upload", "Upload a specific measurement")
+	cmd := root.Command("upload", "Upload measurement files")
+	files := cmd.Arg("file", "OONI measurement files or result bundles to upload").Required().Strings()
 
 	cmd.Action(func(_ *kingpin.ParseContext) error {
-		log.Info("Uploading")
-		log.Error("this function is not implemented")
-		return nil
+		probe, err := root.Init()
+		if err != nil {
+			log.WithError(err).Error("failed to initialize root context")
+			return err
+		}
+		sess, err := probe.NewSession()
+		if err != nil {
+			log.WithError(err).Error("failed to create a measurement session")
+			return err
+		}
+		defer sess.Close()
+		if err := sess.MaybeLookupBackends(); err != nil {
+			log.WithError(err).Error("failed to discover OONI backends")
+			return err
+		}
+		// Interrupting the upload cancels the context, so that we do
+		// not keep retrying the submission of the current measurement.
+		ctx, cancel := context.WithCancel(context.Background())
+		defer cancel()
+		sigs := make(chan os.Signal, 1)
+		signal.Notify(sigs, os.Interrupt, syscall.SIGTERM)
+		defer signal.Stop(sigs)
+		go func() {
+			select {
+			case <-sigs:
+				log.Info("caught a stop signal, shutting down cleanly")
+				cancel()
+			case <-ctx.Done():
+			}
+		}()
+		client, err := sess.NewProbeServicesClient(ctx)
+		if err != nil {
+			log.WithError(err).Error("failed to create probe services client")
+			return err
+		}
+		submitter := probeservices.NewSubmitter(client, log.Log)
+		return doupload(ctx, douploadconfig{
+			Files:      *files,
+			TempDir:    probe.TempDir(),
+			Logger:     log.Log,
+			MaxRetries: 3,
+			Backoff:    2 * time.Second,
+			Submit:     submitter.Submit,
+		})
 	})
 }
+
+type douploadconfig struct {
+	Files      []string
+	TempDir    string
+	Logger     log.Interface
+	MaxRetries int
+	Backoff    time.Duration
+	Submit     func(ctx context.Context, m *model.Measurement) error
+}
+
+// doupload submits all the measurements contained in the configured
+// files. Each file contains one or more JSON measurements, which allows
+// us to submit both files saved by ooniprobe and legacy reports, or is a
+// bundle written by `bundle export-result` on an air-gapped probe.
+func doupload(ctx context.Context, config douploadconfig) error {
+	var failed int
+	for _, file := range config.Files {
+		upload := uploadFile
+		if strings.HasSuffix(file, ".tar.gz") {
+			upload = uploadBundle
+		}
+		if err := upload(ctx, config, file); err != nil {
+			config.Logger.WithError(err).Errorf("failed to upload %s", file)
+			failed++
+		}
+	}
+	if failed > 0 {
+		return errors.Errorf("failed to upload %d files", failed)
+	}
+	return nil
+}
+
+func uploadBundle(ctx context.Context, config douploadconfig, file string) error {
+	fp, err := os.Open(file)
+	if err != nil {
+		return err
+	}
+	defer fp.Close()
+	dir, err := ioutil.TempDir(config.TempDir, "bundle")
+	if err != nil {
+		return err
+	}
+	defer os.RemoveAll(dir)
+	names, err := bundle.Extract(fp, dir)
+	if err != nil {
+		return err
+	}
+	var failed int
+	for _, name := range names {
+		if name == bundle.ManifestName {
+			continue
+		}
+		if err := uploadFile(ctx, config, filepath.Join(dir, filepath.FromSlash(name))); err != nil {
+			config.Logger.WithError(err).Errorf("failed to upload %s from %s", name, file)
+			failed++
+		}
+	}
+	if failed > 0 {
+		return errors.Errorf("failed to upload %d files of the bundle", failed)
+	}
+	return nil
+}
+
+func uploadFile(ctx context.Context, config douploadconfig, file string) error {
+	fp, err := os.Open(file)
+	if err != nil {
+		return err
+	}
+	defer fp.Close()
+	reader := msmtstream.NewReader(fp)
+	for idx := 0; ; idx++ {
+		raw, err := reader.Next()
+		if err == io.EOF {
+			return nil
+		}
+		if err != nil {
+			return errors.Wrapf(err, "reading measurement #%d", idx)
+		}
+		var m model.Measurement
+		if err := json.Unmarshal(raw, &m); err != nil {
+			return errors.Wrapf(err, "parsing measurement #%d", idx)
+		}
+		if err := validate(&m); err != nil {
+			return errors.Wrapf(err, "validating measurement #%d", idx)
+		}
+		submitted, trimmed, err := msmttrim.Trim(&m, msmttrim.MaxSize)
+		if err != nil {
+			return errors.Wrapf(err, "trimming measurement #%d", idx)
+		}
+		if len(trimmed) > 0 {
+			config.Logger.Infof("Trimmed %d fields of measurement #%d", len(trimmed), idx)
+		}
+		if err := submitWithBackoff(ctx, config, submitted); err != nil {
+			return errors.Wrapf(err, "submitting measurement #%d", idx)
+		}
+		config.Logger.WithFields(log.Fields{
+			"file":      file,
+			"index":     idx,
+			"test_name": m.TestName,
+			"report_id": m.ReportID,
+		}).Info("measurement uploaded")
+	}
+}
+
+// validate checks whether the measurement contains the fields that the
+// collector requires to accept it.
+func validate(m *model.Measurement) error {
+	switch {
+	case m.TestName == "":
+		return errors.New("missing test_name")
+	case m.TestVersion == "":
+		return errors.New("missing test_version")
+	case m.MeasurementStartTime == "":
+		return errors.New("missing measurement_start_time")
+	case m.ProbeCC == "":
+		return errors.New("missing probe_cc")
+	case m.ProbeASN == "":
+		return errors.New("missing probe_asn")
+	case m.SoftwareName == "":
+		return errors.New("missing software_name")
+	case m.SoftwareVersion == "":
+		return errors.New("missing software_version")
+	case m.DataFormatVersion == "":
+		return errors.New("missing data_format_version")
+	case m.TestKeys == nil:
+		return errors.New("missing test_keys")
+	}
+	return nil
+}
+
+func submitWithBackoff(ctx context.Context, config douploadconfig, m *model.Measurement) error {
+	backoff := config.Backoff
+	var err error
+	for attempt := 0; attempt <= config.MaxRetries; attempt++ {
+		if attempt > 0 {
+			config.Logger.WithError(err).Warnf("submission failed; retrying in %s", backoff)
+			select {
+			case <-ctx.Done():
+				return ctx.Err()
+			case <-time.After(backoff):
+			}
+			backoff *= 2
+		}
+		if err = config.Submit(ctx, m); err == nil {
+			return nil
+		}
+	}
+	return err
+}
@@ -0,0 +1,144 @@
+package upload
+
+import (
+	"context"
+	"errors"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/apex/log"
+	"github.com/ooni/probe-cli/internal/bundle"
+	"github.com/ooni/probe-cli/internal/oonitest"
+	"github.com/ooni/probe-engine/model"
+)
+
+func newConfig(files []string, submit func(context.Context, *model.Measurement) error) douploadconfig {
+	return douploadconfig{
+		Files: files,
+		Logger: &log.Logger{
+			Handler: &oonitest.FakeLoggerHandler{},
+			Level:   log.DebugLevel,
+		},
+		MaxRetries: 2,
+		Submit:     submit,
+	}
+}
+
+func TestUploadSuccess(t *testing.T) {
+	var count int
+	err := doupload(context.Background(), newConfig(
+		[]string{"testdata/report.jsonl"},
+		func(ctx context.Context, m *model.Measurement) error {
+			count++
+			m.ReportID = "antani"
+			return nil
+		},
+	))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if count != 2 {
+		t.Fatal("unexpected number of submitted measurements")
+	}
+}
+
+func TestUploadRetries(t *testing.T) {
+	var count int
+	err := doupload(context.Background(), newConfig(
+		[]string{"testdata/report.jsonl"},
+		func(ctx context.Context, m *model.Measurement) error {
+			count++
+			if count%2 == 1 {
+				return errors.New("mocked error")
+			}
+			return nil
+		},
+	))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if count != 4 {
+		t.Fatal("unexpected number of submission attempts")
+	}
+}
+
+func TestUploadPersistentFailure(t *testing.T) {
+	var count int
+	err := doupload(context.Background(), newConfig(
+		[]string{"testdata/report.jsonl"},
+		func(ctx context.Context, m *model.Measurement) error {
+			count++
+			return errors.New("mocked error")
+		},
+	))
+	if err == nil {
+		t.Fatal("expected an error here")
+	}
+	if count != 3 {
+		t.Fatal("unexpected number of submission attempts")
+	}
+}
+
+func TestUploadInvalidMeasurement(t *testing.T) {
+	err := doupload(context.Background(), newConfig(
+		[]string{"testdata/invalid.json", "testdata/nonexistent.json"},
+		func(ctx context.Context, m *model.Measurement) error {
+			t.Fatal("should not be called")
+			return nil
+		},
+	))
+	if err == nil || err.Error() != "failed to upload 2 files" {
+		t.Fatalf("not the error we expected: %+v", err)
+	}
+}
+
+func TestUploadBackoffHonoursContext(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	config := newConfig(
+		[]string{"testdata/report.jsonl"},
+		func(ctx context.Context, m *model.Measurement) error {
+			cancel()
+			return errors.New("mocked error")
+		},
+	)
+	config.Backoff = time.Hour
+	err := submitWithBackoff(ctx, config, &model.Measurement{})
+	if !errors.Is(err, context.Canceled) {
+		t.Fatalf("not the error we expected: %+v", err)
+	}
+}
+
+func TestUploadBundle(t *testing.T) {
+	dir, err := ioutil.TempDir("", "ooniprobe-upload")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+	path := filepath.Join(dir, "result.tar.gz")
+	fp, err := os.Create(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	err = bundle.Write(fp, map[string]string{"msmt-1.json": "testdata/report.jsonl"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := fp.Close(); err != nil {
+		t.Fatal(err)
+	}
+	var count int
+	config := newConfig([]string{path}, func(ctx context.Context, m *model.Measurement) error {
+		count++
+		return nil
+	})
+	config.TempDir = dir
+	if err := doupload(context.Background(), config); err != nil {
+		t.Fatal(err)
+	}
+	if count != 2 {
+		t.Fatal("unexpected number of submitted measurements")
+	}
+}
@@ -0,0 +1,109 @@
+package watch
+
+import (
+	"time"
+
+	"github.com/alecthomas/kingpin"
+	"github.com/apex/log"
+	"github.com/ooni/probe-cli/internal/cli/onboard"
+	"github.com/ooni/probe-cli/internal/cli/root"
+	"github.com/ooni/probe-cli/internal/errcode"
+	"github.com/ooni/probe-cli/internal/nettests"
+	"github.com/ooni/probe-cli/internal/netwatch"
+	"github.com/ooni/probe-cli/internal/ooni"
+	"github.com/pkg/errors"
+)
+
+// lookupLocation performs a geolocation and returns the location.
+func lookupLocation(probe *ooni.Probe) (netwatch.Location, error) {
+	engine, err := probe.NewProbeEngine()
+	if err != nil {
+		return netwatch.Location{}, err
+	}
+	defer engine.Close()
+	if err := engine.MaybeLookupLocation(); err != nil {
+		return netwatch.Location{}, errcode.New(errcode.GeolocationFailed, err)
+	}
+	return netwatch.Location{
+		ASN:         engine.ProbeASNString(),
+		CountryCode: engine.ProbeCC(),
+	}, nil
+}
+
+// check looks up the location once and runs the given group, if
+// any, when the location has changed since the previous check.
+func check(probe *ooni.Probe, watcher *netwatch.Watcher, groupName string) error {
+	cur, err := lookupLocation(probe)
+	if err != nil {
+		return err
+	}
+	prev, changed, err := watcher.Update(cur)
+	if err != nil {
+		return err
+	}
+	if !changed {
+		log.Debugf("still on %s (%s)", cur.ASN, cur.CountryCode)
+		return nil
+	}
+	log.WithFields(log.Fields{
+		"type":                  "network_changed",
+		"previous_asn":          prev.ASN,
+		"previous_country_code": prev.CountryCode,
+		"asn":                   cur.ASN,
+		"country_code":          cur.CountryCode,
+		"rerun_group_name":      groupName,
+	}).Infof("Network changed from %s (%s) to %s (%s)",
+		prev.ASN, prev.CountryCode, cur.ASN, cur.CountryCode)
+	if groupName == "" {
+		return nil
+	}
+	conf := nettests.RunGroupConfig{GroupName: groupName, Probe: probe}
+	return nettests.RunGroup(conf)
+}
+
+// sleep sleeps for the given interval unless the probe is terminated.
+func sleep(probe *ooni.Probe, interval time.Duration) {
+	select {
+	case <-probe.Terminated():
+	case <-time.After(interval):
+	}
+}
+
+func init() {
+	cmd := root.Command("watch", "Watch for network changes and optionally run tests when they happen")
+	interval := cmd.Flag("interval", "How often to check the network").Default("10m").Duration()
+	rerun := cmd.Flag("rerun", "Test group to run when the network changes").String()
+
+	cmd.Action(func(_ *kingpin.ParseContext) error {
+		if *rerun != "" {
+			if _, found := nettests.All[*rerun]; !found {
+				return errors.Errorf("unknown test group: %s", *rerun)
+			}
+		}
+		probe, err := root.Init()
+		if err != nil {
+			log.WithError(err).Error("failed to initialize root context")
+			return err
+		}
+		if *rerun != "" {
+			if err := onboard.MaybeOnboarding(probe); err != nil {
+				log.WithError(err).Error("failed to perform onboarding")
+				return err
+			}
+		}
+		kvstore, err := probe.NewKVStore()
+		if err != nil {
+			return err
+		}
+		watcher := netwatch.NewWatcher(kvstore)
+		probe.ListenForSignals()
+		for !probe.IsTerminated() {
+			if err := check(probe, watcher, *rerun); err != nil {
+				log.WithError(err).WithField(
+					"error_code", errcode.Of(err)).Warn("network check failed")
+			}
+			sleep(probe, *interval)
+		}
+		return nil
+	})
+}
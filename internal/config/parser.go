@@ -62,6 +62,17 @@ type Config struct {
 	Nettests Nettests `json:"nettests"`
 	Advanced Advanced `json:"advanced"`
 
+	// RiskProfileOverrides allows the user to override the risk profiles
+	// shipped with the probe field by field. The ZZ key applies to all the
+	// countries without a specific shipped profile.
+	RiskProfileOverrides map[string]RiskProfile `json:"risk_profile_overrides,omitempty"`
+
+	// Profiles contains the named profiles, e.g., "home" and "travel".
+	Profiles map[string]Profile `json:"profiles,omitempty"`
+
+	// ActiveProfileName is the name of the active profile, if any.
+	ActiveProfileName string `json:"active_profile,omitempty"`
+
 	mutex sync.Mutex
 	path  string
 }
@@ -93,7 +104,8 @@ func (c *Config) Unlock() {
 // MaybeMigrate checks the current config version and the config file on disk
 // and if necessary performs and upgrade of the configuration file.
 func (c *Config) MaybeMigrate() error {
-	if c.Version < ConfigVersion {
+	unfrozen := c.Nettests.unfreezeCategoryCodes()
+	if c.Version < ConfigVersion || unfrozen {
 		return c.Write()
 	}
 	return nil
@@ -0,0 +1,58 @@
+package config
+
+import "github.com/pkg/errors"
+
+// Profile contains the settings that depend on where we are measuring
+// from or on behalf of whom, e.g., "home" and "travel". When a profile
+// is active, its settings take precedence over the global ones.
+type Profile struct {
+	// InformedConsent indicates whether the user has given informed
+	// consent to measure using this profile.
+	InformedConsent bool `json:"informed_consent"`
+
+	// WebsitesEnabledCategoryCodes overrides the global categories
+	// when it is not nil.
+	WebsitesEnabledCategoryCodes []string `json:"websites_enabled_category_codes,omitempty"`
+
+	// Annotations are added to every measurement.
+	Annotations map[string]string `json:"annotations,omitempty"`
+}
+
+// ActiveProfile returns the name and the settings of the active profile
+// and whether there is an active profile.
+func (c *Config) ActiveProfile() (string, Profile, bool) {
+	if c.ActiveProfileName == "" {
+		return "", Profile{}, false
+	}
+	p, found := c.Profiles[c.ActiveProfileName]
+	return c.ActiveProfileName, p, found
+}
+
+// UseProfile activates the profile with the given name. An empty
+// name deactivates the active profile, if any.
+func (c *Config) UseProfile(name string) error {
+	if _, found := c.Profiles[name]; name != "" && !found {
+		return errors.Errorf("no such profile: %s", name)
+	}
+	c.ActiveProfileName = name
+	return nil
+}
+
+// HasInformedConsent returns whether the user has given informed consent
+// both globally and for the active profile, if any.
+func (c *Config) HasInformedConsent() bool {
+	if _, p, found := c.ActiveProfile(); found && !p.InformedConsent {
+		return false
+	}
+	return c.InformedConsent
+}
+
+// EnabledCategoryCodes returns the category codes enabled for the given
+// country taking into account the active profile and the risk profile.
+func (c *Config) EnabledCategoryCodes(cc string) []string {
+	nettests := c.Nettests
+	if _, p, found := c.ActiveProfile(); found && p.WebsitesEnabledCategoryCodes != nil {
+		nettests.WebsitesEnabledCategoryCodes = p.WebsitesEnabledCategoryCodes
+	}
+	return nettests.EnabledCategoryCodes(c.RiskProfile(cc))
+}
@@ -0,0 +1,31 @@
+package config
+
+import "testing"
+
+func TestConfigProfiles(t *testing.T) {
+	c := &Config{InformedConsent: true, Profiles: map[string]Profile{
+		"travel": {WebsitesEnabledCategoryCodes: []string{"NEWS"}},
+	}}
+	if _, _, found := c.ActiveProfile(); found {
+		t.Fatal("expected no active profile")
+	}
+	if err := c.UseProfile("home"); err == nil {
+		t.Fatal("expected an error for a nonexistent profile")
+	}
+	if err := c.UseProfile("travel"); err != nil {
+		t.Fatal(err)
+	}
+	if c.HasInformedConsent() {
+		t.Fatal("expected the profile to require consent")
+	}
+	codes := c.EnabledCategoryCodes("IT")
+	if len(codes) != 1 || codes[0] != "NEWS" {
+		t.Fatalf("unexpected categories: %+v", codes)
+	}
+	if err := c.UseProfile(""); err != nil {
+		t.Fatal(err)
+	}
+	if !c.HasInformedConsent() {
+		t.Fatal("expected the global consent")
+	}
+}
@@ -0,0 +1,109 @@
+package config
+
+import (
+	"encoding/json"
+	"strings"
+)
+
+// defaultRiskProfileKey is the key of the profile used for countries
+// for which we do not have a specific profile.
+const defaultRiskProfileKey = "ZZ"
+
+// RiskProfile contains the safety related defaults for a country.
+type RiskProfile struct {
+	// SensitiveCategoryCodes contains the website categories that
+	// are only tested if the user explicitly opts in. When overriding
+	// a profile, nil means that we keep the shipped value.
+	SensitiveCategoryCodes []string `json:"sensitive_category_codes"`
+}
+
+// IsSensitiveCategoryCode returns whether the given category is opt-in.
+func (rp RiskProfile) IsSensitiveCategoryCode(code string) bool {
+	for _, c := range rp.SensitiveCategoryCodes {
+		if strings.EqualFold(c, code) {
+			return true
+		}
+	}
+	return false
+}
+
+// DefaultCategoryCodes returns the categories enabled by default,
+// i.e., all the known categories except the sensitive ones.
+func (rp RiskProfile) DefaultCategoryCodes() []string {
+	var out []string
+	for _, c := range websiteCategories {
+		if !rp.IsSensitiveCategoryCode(c) {
+			out = append(out, c)
+		}
+	}
+	return out
+}
+
+// riskProfilesJSON contains the risk profiles shipped with the probe
+// indexed by country code. The ZZ profile is the default one.
+const riskProfilesJSON = `{
+  "ZZ": {
+    "sensitive_category_codes": ["LGBT", "PORN"]
+  },
+  "CN": {
+    "sensitive_category_codes": ["ANON", "HUMR", "LGBT", "POLR", "PORN"]
+  },
+  "IR": {
+    "sensitive_category_codes": ["LGBT", "POLR", "PORN", "REL"]
+  },
+  "RU": {
+    "sensitive_category_codes": ["LGBT", "PORN"]
+  },
+  "SA": {
+    "sensitive_category_codes": ["LGBT", "PORN", "REL"]
+  }
+}`
+
+var riskProfiles = mustParseRiskProfiles(riskProfilesJSON)
+
+func mustParseRiskProfiles(data string) map[string]RiskProfile {
+	var out map[string]RiskProfile
+	if err := json.Unmarshal([]byte(data), &out); err != nil {
+		panic(err)
+	}
+	if _, found := out[defaultRiskProfileKey]; !found {
+		panic("missing default risk profile")
+	}
+	return out
+}
+
+// DefaultRiskProfile returns the default risk profile.
+func DefaultRiskProfile() RiskProfile {
+	return riskProfiles[defaultRiskProfileKey]
+}
+
+// LookupRiskProfile returns the risk profile shipped with the probe
+// for the given country code or the default profile.
+func LookupRiskProfile(cc string) RiskProfile {
+	if rp, found := riskProfiles[strings.ToUpper(cc)]; found {
+		return rp
+	}
+	return DefaultRiskProfile()
+}
+
+// merge returns a copy of the profile where the fields that are set
+// in the given override replace the corresponding fields.
+func (rp RiskProfile) merge(override RiskProfile) RiskProfile {
+	if override.SensitiveCategoryCodes != nil {
+		rp.SensitiveCategoryCodes = override.SensitiveCategoryCodes
+	}
+	return rp
+}
+
+// RiskProfile returns the risk profile for the given country code taking
+// into account the overrides specified by the user, if any. We merge the
+// overrides on top of the shipped profile. The ZZ override only applies to
+// the countries for which we do not ship a specific profile.
+func (c *Config) RiskProfile(cc string) RiskProfile {
+	cc = strings.ToUpper(cc)
+	rp, shipped := riskProfiles[cc]
+	if !shipped {
+		rp = DefaultRiskProfile().merge(c.RiskProfileOverrides[defaultRiskProfileKey])
+	}
+	return rp.merge(c.RiskProfileOverrides[cc])
+}
@@ -0,0 +1,63 @@
+package config
+
+import (
+	"encoding/json"
+	"testing"
+)
+
+func TestLookupRiskProfile(t *testing.T) {
+	if rp := LookupRiskProfile("ir"); !rp.IsSensitiveCategoryCode("POLR") {
+		t.Fatal("unexpected IR risk profile")
+	}
+	rp := LookupRiskProfile("IT")
+	if rp.IsSensitiveCategoryCode("POLR") || !rp.IsSensitiveCategoryCode("PORN") {
+		t.Fatal("expected the default risk profile")
+	}
+}
+
+func TestConfigRiskProfileOverrides(t *testing.T) {
+	c := &Config{RiskProfileOverrides: map[string]RiskProfile{
+		"IR": {SensitiveCategoryCodes: []string{"LGBT"}},
+	}}
+	if rp := c.RiskProfile("IR"); rp.IsSensitiveCategoryCode("POLR") {
+		t.Fatal("the override was not applied")
+	}
+	if rp := c.RiskProfile("CN"); !rp.IsSensitiveCategoryCode("POLR") {
+		t.Fatal("expected the shipped CN profile")
+	}
+}
+
+func TestConfigRiskProfilePartialOverride(t *testing.T) {
+	var c Config
+	data := []byte(`{"risk_profile_overrides":{"IR":{},"SA":{"sensitive_category_codes":null}}}`)
+	if err := json.Unmarshal(data, &c); err != nil {
+		t.Fatal(err)
+	}
+	for _, cc := range []string{"IR", "SA"} {
+		rp := c.RiskProfile(cc)
+		for _, code := range LookupRiskProfile(cc).SensitiveCategoryCodes {
+			if !rp.IsSensitiveCategoryCode(code) {
+				t.Fatalf("%s: lost shipped sensitive category: %s", cc, code)
+			}
+		}
+	}
+	data = []byte(`{"risk_profile_overrides":{"IR":{"sensitive_category_codes":["LGBT"]}}}`)
+	if err := json.Unmarshal(data, &c); err != nil {
+		t.Fatal(err)
+	}
+	if rp := c.RiskProfile("IR"); rp.IsSensitiveCategoryCode("POLR") || !rp.IsSensitiveCategoryCode("LGBT") {
+		t.Fatal("the override was not applied")
+	}
+}
+
+func TestConfigRiskProfileDefaultOverride(t *testing.T) {
+	c := &Config{RiskProfileOverrides: map[string]RiskProfile{
+		"ZZ": {SensitiveCategoryCodes: []string{}},
+	}}
+	if rp := c.RiskProfile("IT"); rp.IsSensitiveCategoryCode("PORN") {
+		t.Fatal("expected the ZZ override for countries without a profile")
+	}
+	if rp := c.RiskProfile("IR"); !rp.IsSensitiveCategoryCode("POLR") {
+		t.Fatal("the ZZ override must not replace the shipped IR profile")
+	}
+}
@@ -1,5 +1,7 @@
 package config
 
+import "strings"
+
 var websiteCategories = []string{
 	"ALDR",
 	"ANON",
@@ -41,10 +43,156 @@ type Sharing struct {
 // Advanced settings
 type Advanced struct {
 	SendCrashReports bool `json:"send_crash_reports"`
+
+	// UntrustedDescriptorPolicy is what to do with run descriptors that
+	// are unsigned or signed by an unknown publisher. It is either
+	// DescriptorPolicyWarn or DescriptorPolicyRefuse (the default).
+	UntrustedDescriptorPolicy string `json:"untrusted_descriptor_policy,omitempty"`
+
+	// AllowPrivateTargets allows measuring targets in private, loopback
+	// and link-local address space. We refuse them by default such that
+	// descriptors and URL lists cannot make the probe scan the LAN.
+	AllowPrivateTargets bool `json:"allow_private_targets,omitempty"`
+
+	// ProbeServices overrides the default list of probe services, which
+	// allows to use self-hosted backends. When empty, we use the defaults.
+	ProbeServices []ProbeService `json:"probe_services,omitempty"`
+
+	// DetectLeaks enables checking whether each nettest leaks goroutines
+	// or file descriptors. Leaks are recorded in the run manifest. This
+	// only works when nettests run sequentially.
+	DetectLeaks bool `json:"detect_leaks,omitempty"`
 }
 
+// ProbeService is a probe services backend.
+type ProbeService struct {
+	// Address is the address of the service (e.g. an HTTPS URL).
+	Address string `json:"address"`
+
+	// Type is the type of the service: "https", "onion" or "cloudfront".
+	Type string `json:"type"`
+
+	// Front is the domain to use for domain fronting, if any.
+	Front string `json:"front,omitempty"`
+}
+
+const (
+	// DescriptorPolicyWarn means we run untrusted descriptors after
+	// emitting a warning.
+	DescriptorPolicyWarn = "warn"
+
+	// DescriptorPolicyRefuse means we refuse to run untrusted descriptors.
+	DescriptorPolicyRefuse = "refuse"
+)
+
 // Nettests related settings
 type Nettests struct {
 	WebsitesURLLimit             int64    `json:"websites_url_limit"`
 	WebsitesEnabledCategoryCodes []string `json:"websites_enabled_category_codes"`
+
+	// WebsitesTestSensitive indicates whether the user opted in to test
+	// the sensitive categories of the risk profile of the country we are
+	// measuring from. It only matters when WebsitesEnabledCategoryCodes
+	// is nil, i.e., when the user did not pick the categories.
+	WebsitesTestSensitive bool `json:"websites_test_sensitive"`
+
+	// DoNotMeasureDomains contains domains that we must never measure
+	// even if they appear in the test lists. A domain also matches
+	// all of its subdomains.
+	DoNotMeasureDomains []string `json:"do_not_measure_domains"`
+
+	// DoNotMeasureCategoryCodes contains the category codes of URLs
+	// that we must never measure.
+	DoNotMeasureCategoryCodes []string `json:"do_not_measure_category_codes"`
+
+	// DuplicateWindowSeconds is the time window in which measuring again
+	// the same input with the same experiment on the same network is
+	// considered a duplicate. Zero disables duplicate detection.
+	DuplicateWindowSeconds int64 `json:"duplicate_window_seconds"`
+
+	// DuplicatePolicy is what to do with duplicate measurements. It is
+	// either DuplicatePolicySkip or DuplicatePolicyAnnotate.
+	DuplicatePolicy string `json:"duplicate_policy"`
+
+	// MaxParallelNettests is the maximum number of nettests of the same
+	// group that we run concurrently. Bandwidth intensive nettests always
+	// run alone. Zero or one means we run nettests sequentially.
+	MaxParallelNettests int `json:"max_parallel_nettests"`
+
+	// WebsitesRepetitions is the number of times we measure each URL
+	// within a run. With more than one repetition, we also report the
+	// blocking probability of each URL. Zero means once.
+	WebsitesRepetitions int `json:"websites_repetitions"`
+}
+
+const (
+	// DuplicatePolicySkip means we skip duplicate measurements.
+	DuplicatePolicySkip = "skip"
+
+	// DuplicatePolicyAnnotate means we perform duplicate measurements
+	// anyway but we annotate them as duplicates.
+	DuplicatePolicyAnnotate = "annotate"
+)
+
+// EnabledCategoryCodes returns the category codes approved by the user. If
+// the user did not pick the categories, we return the default categories
+// of the given risk profile, plus its sensitive categories if the user opted
+// in to test them. We silently ignore unknown category codes.
+func (n Nettests) EnabledCategoryCodes(rp RiskProfile) []string {
+	if n.WebsitesEnabledCategoryCodes == nil {
+		if n.WebsitesTestSensitive {
+			return append([]string{}, websiteCategories...)
+		}
+		return rp.DefaultCategoryCodes()
+	}
+	var out []string
+	for _, code := range n.WebsitesEnabledCategoryCodes {
+		code = strings.ToUpper(code)
+		for _, c := range websiteCategories {
+			if c == code {
+				out = append(out, c)
+				break
+			}
+		}
+	}
+	return out
+}
+
+// unfreezeCategoryCodes replaces the category codes written by previous
+// versions of the onboarding, which were the default categories of the ZZ
+// risk profile with or without its sensitive categories, with the sensitive
+// opt-in flag, such that we use the risk profile of the country we are
+// measuring from. Returns whether it changed the settings.
+func (n *Nettests) unfreezeCategoryCodes() bool {
+	if n.WebsitesEnabledCategoryCodes == nil {
+		return false
+	}
+	rp := DefaultRiskProfile()
+	switch {
+	case sameCategoryCodes(n.WebsitesEnabledCategoryCodes, rp.DefaultCategoryCodes()):
+		n.WebsitesTestSensitive = false
+	case sameCategoryCodes(n.WebsitesEnabledCategoryCodes, websiteCategories):
+		n.WebsitesTestSensitive = true
+	default:
+		return false
+	}
+	n.WebsitesEnabledCategoryCodes = nil
+	return true
+}
+
+// sameCategoryCodes returns whether a and b contain the same codes.
+func sameCategoryCodes(a, b []string) bool {
+	set := make(map[string]bool)
+	for _, code := range a {
+		set[strings.ToUpper(code)] = true
+	}
+	if len(set) != len(b) {
+		return false
+	}
+	for _, code := range b {
+		if !set[code] {
+			return false
+		}
+	}
+	return true
 }
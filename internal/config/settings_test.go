@@ -0,0 +1,53 @@
+package config
+
+import "testing"
+
+func TestEnabledCategoryCodesDefault(t *testing.T) {
+	var n Nettests
+	rp := DefaultRiskProfile()
+	codes := n.EnabledCategoryCodes(rp)
+	if len(codes) != len(websiteCategories)-len(rp.SensitiveCategoryCodes) {
+		t.Fatal("unexpected number of category codes")
+	}
+	for _, c := range codes {
+		if rp.IsSensitiveCategoryCode(c) {
+			t.Fatalf("sensitive category enabled by default: %s", c)
+		}
+	}
+}
+
+func TestEnabledCategoryCodesUserProvided(t *testing.T) {
+	n := Nettests{WebsitesEnabledCategoryCodes: []string{"porn", "NEWS", "XYZ"}}
+	codes := n.EnabledCategoryCodes(DefaultRiskProfile())
+	if len(codes) != 2 || codes[0] != "PORN" || codes[1] != "NEWS" {
+		t.Fatalf("unexpected category codes: %+v", codes)
+	}
+}
+
+func TestEnabledCategoryCodesRiskProfile(t *testing.T) {
+	var n Nettests
+	for _, c := range n.EnabledCategoryCodes(LookupRiskProfile("IR")) {
+		if c == "POLR" || c == "REL" {
+			t.Fatalf("sensitive category enabled by default in IR: %s", c)
+		}
+	}
+	n.WebsitesTestSensitive = true
+	if codes := n.EnabledCategoryCodes(LookupRiskProfile("IR")); len(codes) != len(websiteCategories) {
+		t.Fatal("expected all the categories after opting in")
+	}
+}
+
+func TestUnfreezeCategoryCodes(t *testing.T) {
+	n := Nettests{WebsitesEnabledCategoryCodes: DefaultRiskProfile().DefaultCategoryCodes()}
+	if !n.unfreezeCategoryCodes() || n.WebsitesEnabledCategoryCodes != nil || n.WebsitesTestSensitive {
+		t.Fatal("expected to unfreeze the default categories")
+	}
+	n = Nettests{WebsitesEnabledCategoryCodes: append([]string{}, websiteCategories...)}
+	if !n.unfreezeCategoryCodes() || n.WebsitesEnabledCategoryCodes != nil || !n.WebsitesTestSensitive {
+		t.Fatal("expected to unfreeze all the categories")
+	}
+	n = Nettests{WebsitesEnabledCategoryCodes: []string{"NEWS"}}
+	if n.unfreezeCategoryCodes() || len(n.WebsitesEnabledCategoryCodes) != 1 {
+		t.Fatal("expected to keep the user provided categories")
+	}
+}
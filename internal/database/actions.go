@@ -175,6 +175,184 @@ func DeleteResult(sess sqlbuilder.Database, resultID int64) error {
 	return nil
 }
 
+// sqliteTimeFormat is the format in which the sqlite driver stores times.
+const sqliteTimeFormat = "2006-01-02 15:04:05.999999999-07:00"
+
+// HasRecentMeasurement returns whether there is a successful measurement
+// for the given test name and URL performed on the given ASN since the given
+// time. We ignore the measurements belonging to the excluded result, which
+// is typically the result we are currently running. We compare times using
+// julianday, which parses the stored times, because comparing the stored
+// strings with a bound time.Time may not compare instants.
+func HasRecentMeasurement(sess sqlbuilder.Database, testName string, urlID sql.NullInt64, asn uint, since time.Time, excludedResultID int64) (bool, error) {
+	measurements := []Measurement{}
+	req := sess.Select(db.Raw("measurements.*")).From("measurements").
+		Join("results").On("results.result_id = measurements.result_id").
+		Join("networks").On("results.network_id = networks.network_id").
+		Where("measurements.test_name = ?", testName).
+		And("measurements.measurement_is_done = ?", true).
+		And("measurements.measurement_is_failed = ?", false).
+		And(db.Raw("julianday(measurements.measurement_start_time) >= julianday(?)",
+			since.UTC().Format(sqliteTimeFormat))).
+		And("networks.asn = ?", asn).
+		And("results.result_id != ?", excludedResultID)
+	if urlID.Valid {
+		req = req.And("measurements.url_id = ?", urlID.Int64)
+	} else {
+		req = req.And("measurements.url_id IS NULL")
+	}
+	if err := req.Limit(1).All(&measurements); err != nil {
+		log.Errorf("failed to run query %s: %v", req.String(), err)
+		return false, err
+	}
+	return len(measurements) > 0, nil
+}
+
+// HasReport returns whether we have stored any measurement belonging
+// to the report with the given ID.
+func HasReport(sess sqlbuilder.Database, reportID string) (bool, error) {
+	count, err := sess.Collection("measurements").Find(
+		db.Cond{"report_id": reportID},
+	).Count()
+	if err != nil {
+		return false, errors.Wrap(err, "counting the report measurements")
+	}
+	return count > 0, nil
+}
+
+// Estimate is the estimated cost of running a test group.
+type Estimate struct {
+	// SampleSize is the number of results used for the estimate. When
+	// it is zero, we have no data to estimate the cost.
+	SampleSize int
+
+	// DataUsageUp is the estimated upload data usage in KiB.
+	DataUsageUp float64
+
+	// DataUsageDown is the estimated download data usage in KiB.
+	DataUsageDown float64
+
+	// Runtime is the estimated runtime in fractional seconds.
+	Runtime float64
+}
+
+// estimateSampleSize is the maximum number of recent results we use
+// to estimate the cost of running a test group.
+const estimateSampleSize = 10
+
+// EstimateResult estimates the cost of running the given test group using
+// the data usage and the runtime of the most recent results.
+func EstimateResult(sess sqlbuilder.Database, testGroupName string) (Estimate, error) {
+	var (
+		estimate Estimate
+		results  []Result
+	)
+	res := sess.Collection("results").Find(db.Cond{
+		"test_group_name": testGroupName,
+		"result_is_done":  true,
+	}).OrderBy("-result_start_time").Limit(estimateSampleSize)
+	if err := res.All(&results); err != nil {
+		return estimate, errors.Wrap(err, "listing recent results")
+	}
+	for _, r := range results {
+		estimate.DataUsageUp += r.DataUsageUp
+		estimate.DataUsageDown += r.DataUsageDown
+		estimate.Runtime += r.Runtime
+	}
+	if n := len(results); n > 0 {
+		estimate.SampleSize = n
+		estimate.DataUsageUp /= float64(n)
+		estimate.DataUsageDown /= float64(n)
+		estimate.Runtime /= float64(n)
+	}
+	return estimate, nil
+}
+
+// BlockingProbability summarizes the repeated measurements of a URL.
+type BlockingProbability struct {
+	URLID     int64
+	URL       string
+	Total     int
+	Anomalies int
+}
+
+// Probability returns the fraction of anomalous measurements.
+func (bp BlockingProbability) Probability() float64 {
+	if bp.Total <= 0 {
+		return 0
+	}
+	return float64(bp.Anomalies) / float64(bp.Total)
+}
+
+// ListBlockingProbabilities returns, for each URL measured by the given test
+// within the given result, how many successful measurements were anomalous.
+// The URLs are returned in the order in which we first measured them.
+func ListBlockingProbabilities(sess sqlbuilder.Database, resultID int64, testName string) ([]BlockingProbability, error) {
+	measurements, err := ListMeasurements(sess, resultID)
+	if err != nil {
+		return nil, err
+	}
+	var out []BlockingProbability
+	index := make(map[int64]int)
+	for _, msmt := range measurements {
+		if msmt.TestName != testName || !msmt.URLID.Valid {
+			continue
+		}
+		if !msmt.Measurement.IsDone || msmt.IsFailed || !msmt.IsAnomaly.Valid {
+			continue
+		}
+		idx, found := index[msmt.URLID.Int64]
+		if !found {
+			idx = len(out)
+			index[msmt.URLID.Int64] = idx
+			out = append(out, BlockingProbability{
+				URLID: msmt.URLID.Int64,
+				URL:   msmt.URL.URL.String,
+			})
+		}
+		out[idx].Total++
+		if msmt.IsAnomaly.Bool {
+			out[idx].Anomalies++
+		}
+	}
+	return out, nil
+}
+
+// UploadBacklog describes the measurements we failed to submit.
+type UploadBacklog struct {
+	// Count is the number of measurements we failed to submit.
+	Count uint64
+
+	// Oldest is the start time of the oldest of such measurements. It
+	// is the zero time when Count is zero.
+	Oldest time.Time
+}
+
+// GetUploadBacklog returns the measurements that we have failed to submit
+// and have not been successfully resubmitted afterwards.
+func GetUploadBacklog(sess sqlbuilder.Database) (UploadBacklog, error) {
+	var backlog UploadBacklog
+	res := sess.Collection("measurements").Find(db.Cond{
+		"measurement_is_done":          true,
+		"measurement_is_upload_failed": true,
+		"measurement_is_uploaded":      false,
+	})
+	count, err := res.Count()
+	if err != nil {
+		return backlog, errors.Wrap(err, "counting failed submissions")
+	}
+	if count <= 0 {
+		return backlog, nil
+	}
+	var oldest Measurement
+	if err := res.OrderBy("measurement_start_time").Limit(1).One(&oldest); err != nil {
+		return backlog, errors.Wrap(err, "getting the oldest failed submission")
+	}
+	backlog.Count = count
+	backlog.Oldest = oldest.StartTime
+	return backlog, nil
+}
+
 // CreateMeasurement writes the measurement to the database a returns a pointer
 // to the Measurement
 func CreateMeasurement(sess sqlbuilder.Database, reportID sql.NullString, testName string, measurementDir string, idx int, resultID int64, urlID sql.NullInt64) (*Measurement, error) {
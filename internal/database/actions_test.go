@@ -7,6 +7,7 @@ import (
 	"io/ioutil"
 	"os"
 	"testing"
+	"time"
 
 	db "upper.io/db.v3"
 )
@@ -303,3 +304,425 @@ func TestPerformanceTestKeys(t *testing.T) {
 		t.Fatalf("error Download %f", tk.Download)
 	}
 }
+
+func TestHasRecentMeasurement(t *testing.T) {
+	tmpfile, err := ioutil.TempFile("", "dbtest")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.Remove(tmpfile.Name())
+
+	tmpdir, err := ioutil.TempDir("", "oonitest")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(tmpdir)
+
+	sess, err := Connect(tmpfile.Name())
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	location := locationInfo{
+		asn:         30722,
+		countryCode: "IT",
+		networkName: "Vodafone Italia S.p.A.",
+	}
+	network, err := CreateNetwork(sess, &location)
+	if err != nil {
+		t.Fatal(err)
+	}
+	result, err := CreateResult(sess, tmpdir, "websites", network.ID)
+	if err != nil {
+		t.Fatal(err)
+	}
+	urlID, err := CreateOrUpdateURL(sess, "https://ooni.org/", "NEWS", "XX")
+	if err != nil {
+		t.Fatal(err)
+	}
+	validURLID := sql.NullInt64{Int64: urlID, Valid: true}
+	since := time.Now().Add(-time.Hour)
+
+	reportID := sql.NullString{String: "", Valid: false}
+	msmt, err := CreateMeasurement(
+		sess, reportID, "web_connectivity", tmpdir, 0, result.ID, validURLID)
+	if err != nil {
+		t.Fatal(err)
+	}
+	found, err := HasRecentMeasurement(
+		sess, "web_connectivity", validURLID, 30722, since, 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if found {
+		t.Fatal("measurement not done yet but found")
+	}
+
+	if err := msmt.Done(sess); err != nil {
+		t.Fatal(err)
+	}
+	found, err = HasRecentMeasurement(
+		sess, "web_connectivity", validURLID, 30722, since, 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !found {
+		t.Fatal("expected to find the measurement")
+	}
+
+	found, err = HasRecentMeasurement(
+		sess, "web_connectivity", validURLID, 30722, since, result.ID)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if found {
+		t.Fatal("measurement belongs to the excluded result but found")
+	}
+
+	found, err = HasRecentMeasurement(
+		sess, "web_connectivity", validURLID, 12345, since, 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if found {
+		t.Fatal("measurement performed on another ASN but found")
+	}
+
+	found, err = HasRecentMeasurement(
+		sess, "web_connectivity", validURLID, 30722, time.Now().Add(time.Hour), 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if found {
+		t.Fatal("measurement outside of the window but found")
+	}
+}
+
+func TestEstimateResult(t *testing.T) {
+	tmpfile, err := ioutil.TempFile("", "dbtest")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.Remove(tmpfile.Name())
+
+	tmpdir, err := ioutil.TempDir("", "oonitest")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(tmpdir)
+
+	sess, err := Connect(tmpfile.Name())
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	estimate, err := EstimateResult(sess, "im")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if estimate.SampleSize != 0 {
+		t.Fatal("expected no samples")
+	}
+
+	location := locationInfo{
+		asn:         0,
+		countryCode: "IT",
+		networkName: "Unknown",
+	}
+	network, err := CreateNetwork(sess, &location)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, usage := range []float64{10, 20} {
+		result, err := CreateResult(sess, tmpdir, "im", network.ID)
+		if err != nil {
+			t.Fatal(err)
+		}
+		result.DataUsageUp = usage
+		result.DataUsageDown = usage * 10
+		if err := result.Finished(sess); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if _, err := CreateResult(sess, tmpdir, "im", network.ID); err != nil {
+		t.Fatal(err)
+	}
+
+	estimate, err = EstimateResult(sess, "im")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if estimate.SampleSize != 2 {
+		t.Fatal("unexpected sample size")
+	}
+	if estimate.DataUsageUp != 15 || estimate.DataUsageDown != 150 {
+		t.Fatal("unexpected data usage estimate")
+	}
+}
+
+func TestListBlockingProbabilities(t *testing.T) {
+	tmpfile, err := ioutil.TempFile("", "dbtest")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.Remove(tmpfile.Name())
+
+	tmpdir, err := ioutil.TempDir("", "oonitest")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(tmpdir)
+
+	sess, err := Connect(tmpfile.Name())
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	location := locationInfo{
+		asn:         30722,
+		countryCode: "IT",
+		networkName: "Vodafone Italia S.p.A.",
+	}
+	network, err := CreateNetwork(sess, &location)
+	if err != nil {
+		t.Fatal(err)
+	}
+	result, err := CreateResult(sess, tmpdir, "websites", network.ID)
+	if err != nil {
+		t.Fatal(err)
+	}
+	urlID, err := CreateOrUpdateURL(sess, "https://ooni.org/", "NEWS", "XX")
+	if err != nil {
+		t.Fatal(err)
+	}
+	validURLID := sql.NullInt64{Int64: urlID, Valid: true}
+	reportID := sql.NullString{String: "", Valid: false}
+
+	type summary struct {
+		IsAnomaly bool
+	}
+	for idx, isAnomaly := range []bool{true, false, true, false} {
+		msmt, err := CreateMeasurement(
+			sess, reportID, "web_connectivity", tmpdir, idx, result.ID, validURLID)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if err := AddTestKeys(sess, msmt, summary{IsAnomaly: isAnomaly}); err != nil {
+			t.Fatal(err)
+		}
+		if err := msmt.Done(sess); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	bps, err := ListBlockingProbabilities(sess, result.ID, "web_connectivity")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(bps) != 1 {
+		t.Fatal("expected a single URL")
+	}
+	if bps[0].URL != "https://ooni.org/" || bps[0].Total != 4 || bps[0].Anomalies != 2 {
+		t.Fatalf("unexpected blocking probability: %+v", bps[0])
+	}
+	if bps[0].Probability() != 0.5 {
+		t.Fatal("unexpected probability")
+	}
+}
+
+func TestGetUploadBacklog(t *testing.T) {
+	tmpfile, err := ioutil.TempFile("", "dbtest")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.Remove(tmpfile.Name())
+
+	tmpdir, err := ioutil.TempDir("", "oonitest")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(tmpdir)
+
+	sess, err := Connect(tmpfile.Name())
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	backlog, err := GetUploadBacklog(sess)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if backlog.Count != 0 || !backlog.Oldest.IsZero() {
+		t.Fatal("expected an empty backlog")
+	}
+
+	location := locationInfo{
+		asn:         0,
+		countryCode: "IT",
+		networkName: "Unknown",
+	}
+	network, err := CreateNetwork(sess, &location)
+	if err != nil {
+		t.Fatal(err)
+	}
+	result, err := CreateResult(sess, tmpdir, "im", network.ID)
+	if err != nil {
+		t.Fatal(err)
+	}
+	reportID := sql.NullString{String: "", Valid: false}
+	urlID := sql.NullInt64{Int64: 0, Valid: false}
+	var msmts []*Measurement
+	for idx := 0; idx < 3; idx++ {
+		msmt, err := CreateMeasurement(
+			sess, reportID, "telegram", tmpdir, idx, result.ID, urlID)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if err := msmt.Done(sess); err != nil {
+			t.Fatal(err)
+		}
+		msmts = append(msmts, msmt)
+	}
+	if err := msmts[0].UploadFailed(sess, "generic_timeout_error"); err != nil {
+		t.Fatal(err)
+	}
+	if err := msmts[1].UploadFailed(sess, "generic_timeout_error"); err != nil {
+		t.Fatal(err)
+	}
+	if err := msmts[2].UploadSucceeded(sess); err != nil {
+		t.Fatal(err)
+	}
+
+	backlog, err = GetUploadBacklog(sess)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if backlog.Count != 2 {
+		t.Fatalf("unexpected backlog count: %d", backlog.Count)
+	}
+	if delta := backlog.Oldest.Sub(msmts[0].StartTime); delta > time.Second || delta < -time.Second {
+		t.Fatal("unexpected oldest measurement")
+	}
+}
+
+func TestHasReport(t *testing.T) {
+	tmpfile, err := ioutil.TempFile("", "dbtest")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.Remove(tmpfile.Name())
+
+	tmpdir, err := ioutil.TempDir("", "oonitest")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(tmpdir)
+
+	sess, err := Connect(tmpfile.Name())
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	location := locationInfo{
+		asn:         30722,
+		countryCode: "IT",
+		networkName: "Vodafone Italia S.p.A.",
+	}
+	network, err := CreateNetwork(sess, &location)
+	if err != nil {
+		t.Fatal(err)
+	}
+	result, err := CreateResult(sess, tmpdir, "im", network.ID)
+	if err != nil {
+		t.Fatal(err)
+	}
+	reportID := sql.NullString{String: "20201201T000000Z_AS30722_antani", Valid: true}
+	if _, err := CreateMeasurement(
+		sess, reportID, "telegram", tmpdir, 0, result.ID, sql.NullInt64{}); err != nil {
+		t.Fatal(err)
+	}
+
+	found, err := HasReport(sess, reportID.String)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !found {
+		t.Fatal("expected to find the report")
+	}
+	found, err = HasReport(sess, "20201201T000000Z_AS30722_mascetti")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if found {
+		t.Fatal("did not expect to find the report")
+	}
+}
+
+func TestHasRecentMeasurementWindowBoundaries(t *testing.T) {
+	tmpfile, err := ioutil.TempFile("", "dbtest")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.Remove(tmpfile.Name())
+
+	tmpdir, err := ioutil.TempDir("", "oonitest")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(tmpdir)
+
+	sess, err := Connect(tmpfile.Name())
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	location := locationInfo{
+		asn:         30722,
+		countryCode: "IT",
+		networkName: "Vodafone Italia S.p.A.",
+	}
+	network, err := CreateNetwork(sess, &location)
+	if err != nil {
+		t.Fatal(err)
+	}
+	result, err := CreateResult(sess, tmpdir, "websites", network.ID)
+	if err != nil {
+		t.Fatal(err)
+	}
+	// We express since in a zone other than UTC to check that we
+	// compare instants rather than their string representations.
+	since := time.Now().Add(-time.Hour).In(time.FixedZone("UTC+5", 5*3600))
+
+	var cases = []struct {
+		url       string
+		startTime time.Time
+		expected  bool
+	}{
+		{"https://ooni.org/inside", since.Add(2 * time.Second), true},
+		{"https://ooni.org/outside", since.Add(-2 * time.Second), false},
+	}
+	for idx, c := range cases {
+		urlID, err := CreateOrUpdateURL(sess, c.url, "NEWS", "XX")
+		if err != nil {
+			t.Fatal(err)
+		}
+		validURLID := sql.NullInt64{Int64: urlID, Valid: true}
+		msmt, err := CreateMeasurement(
+			sess, sql.NullString{}, "web_connectivity", tmpdir, idx, result.ID, validURLID)
+		if err != nil {
+			t.Fatal(err)
+		}
+		msmt.StartTime = c.startTime.UTC()
+		if err := msmt.Done(sess); err != nil {
+			t.Fatal(err)
+		}
+		found, err := HasRecentMeasurement(
+			sess, "web_connectivity", validURLID, 30722, since, 0)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if found != c.expected {
+			t.Fatalf("%s: expected %v, got %v", c.url, c.expected, found)
+		}
+	}
+}
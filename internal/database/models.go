@@ -129,6 +129,7 @@ func (m *Measurement) Done(sess sqlbuilder.Database) error {
 // UploadFailed writes the error string for the upload failure to the measurement
 func (m *Measurement) UploadFailed(sess sqlbuilder.Database, failure string) error {
 	m.UploadFailureMsg = sql.NullString{String: failure, Valid: true}
+	m.IsUploadFailed = true
 	m.IsUploaded = false
 
 	err := sess.Collection("measurements").Find("measurement_id", m.ID).Update(m)
@@ -0,0 +1,133 @@
+// Package descriptor contains the run descriptors. A run descriptor is
+// a JSON document describing a structured measurement campaign, i.e.,
+// which nettests to run, with which inputs, which annotations to attach
+// to the measurements and, optionally, which outcome we expect.
+package descriptor
+
+import (
+	"encoding/json"
+	"io/ioutil"
+	"time"
+
+	"github.com/pkg/errors"
+)
+
+// Expected outcomes for Input.ExpectedOutcome
+const (
+	// OutcomeOK means we expect the measurement not to be anomalous.
+	OutcomeOK = "ok"
+
+	// OutcomeAnomaly means we expect the measurement to be anomalous.
+	OutcomeAnomaly = "anomaly"
+)
+
+// Descriptor is a run descriptor.
+type Descriptor struct {
+	// Name is the name of the descriptor.
+	Name string `json:"name"`
+
+	// Description describes the purpose of the descriptor.
+	Description string `json:"description"`
+
+	// Author is the author of the descriptor.
+	Author string `json:"author"`
+
+	// Nettests contains the nettests to run.
+	Nettests []Nettest `json:"nettests"`
+
+	// NotBefore is the optional beginning of the measurement window of
+	// a coordinated campaign. We wait until this time before running, so
+	// that all the probes of the campaign measure at about the same time.
+	NotBefore *time.Time `json:"not_before,omitempty"`
+
+	// NotAfter is the optional end of the measurement window, after
+	// which we refuse to run the descriptor.
+	NotAfter *time.Time `json:"not_after,omitempty"`
+}
+
+// ErrWindowExpired indicates that the measurement window of a
+// descriptor is over and we must therefore not run it.
+var ErrWindowExpired = errors.New("descriptor: measurement window expired")
+
+// Delay returns how long we should wait before running the descriptor
+// at the given time, or ErrWindowExpired if it is too late to run it.
+func (d *Descriptor) Delay(now time.Time) (time.Duration, error) {
+	if d.NotAfter != nil && !now.Before(*d.NotAfter) {
+		return 0, ErrWindowExpired
+	}
+	if d.NotBefore != nil && now.Before(*d.NotBefore) {
+		return d.NotBefore.Sub(now), nil
+	}
+	return 0, nil
+}
+
+// Nettest is a nettest inside a descriptor.
+type Nettest struct {
+	// TestName is the name of the experiment to run.
+	TestName string `json:"test_name"`
+
+	// Annotations are added to every measurement of this nettest.
+	Annotations map[string]string `json:"annotations,omitempty"`
+
+	// Inputs contains the inputs. It is empty for nettests that do
+	// not take any input.
+	Inputs []Input `json:"inputs,omitempty"`
+}
+
+// Input is an input for a nettest inside a descriptor.
+type Input struct {
+	// Input is the input to measure.
+	Input string `json:"input"`
+
+	// Annotations are added to the measurement of this input.
+	Annotations map[string]string `json:"annotations,omitempty"`
+
+	// ExpectedOutcome is the optional expected outcome, which
+	// should be one of OutcomeOK and OutcomeAnomaly.
+	ExpectedOutcome string `json:"expected_outcome,omitempty"`
+}
+
+// Parse parses a descriptor from JSON bytes.
+func Parse(data []byte) (*Descriptor, error) {
+	var d Descriptor
+	if err := json.Unmarshal(data, &d); err != nil {
+		return nil, errors.Wrap(err, "parsing json")
+	}
+	if err := d.Validate(); err != nil {
+		return nil, err
+	}
+	return &d, nil
+}
+
+// Read reads a descriptor from the given file.
+func Read(path string) (*Descriptor, error) {
+	data, err := ioutil.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	return Parse(data)
+}
+
+// Validate checks whether the descriptor is valid.
+func (d *Descriptor) Validate() error {
+	if len(d.Nettests) <= 0 {
+		return errors.New("descriptor without nettests")
+	}
+	if d.NotBefore != nil && d.NotAfter != nil && !d.NotBefore.Before(*d.NotAfter) {
+		return errors.New("not_before must be before not_after")
+	}
+	for _, nt := range d.Nettests {
+		if nt.TestName == "" {
+			return errors.New("nettest without test_name")
+		}
+		for _, input := range nt.Inputs {
+			switch input.ExpectedOutcome {
+			case "", OutcomeOK, OutcomeAnomaly:
+			default:
+				return errors.Errorf(
+					"invalid expected_outcome: %s", input.ExpectedOutcome)
+			}
+		}
+	}
+	return nil
+}
@@ -0,0 +1,68 @@
+package descriptor
+
+import (
+	"testing"
+	"time"
+)
+
+func TestReadValidDescriptor(t *testing.T) {
+	d, err := Read("testdata/valid-descriptor.json")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(d.Nettests) != 2 {
+		t.Fatal("unexpected number of nettests")
+	}
+	wc := d.Nettests[0]
+	if wc.Annotations["campaign"] != "news" {
+		t.Fatal("unexpected nettest annotations")
+	}
+	if wc.Inputs[0].ExpectedOutcome != OutcomeOK {
+		t.Fatal("unexpected expected outcome")
+	}
+	if wc.Inputs[0].Annotations["kind"] != "control" {
+		t.Fatal("unexpected input annotations")
+	}
+}
+
+func TestParseInvalidDescriptors(t *testing.T) {
+	inputs := []string{
+		`{`,
+		`{"nettests": []}`,
+		`{"nettests": [{"test_name": ""}]}`,
+		`{"nettests": [{"test_name": "web_connectivity", "inputs": [
+			{"input": "https://www.example.com/", "expected_outcome": "blocked"}
+		]}]}`,
+	}
+	for _, input := range inputs {
+		if _, err := Parse([]byte(input)); err == nil {
+			t.Fatalf("expected an error for %s", input)
+		}
+	}
+}
+
+func TestDescriptorDelay(t *testing.T) {
+	notBefore := time.Date(2020, 7, 1, 12, 0, 0, 0, time.UTC)
+	notAfter := notBefore.Add(time.Hour)
+	d := &Descriptor{NotBefore: &notBefore, NotAfter: &notAfter}
+	if delay, err := d.Delay(notBefore.Add(-time.Minute)); err != nil || delay != time.Minute {
+		t.Fatal("expected to wait for the window to begin")
+	}
+	if delay, err := d.Delay(notBefore.Add(time.Minute)); err != nil || delay != 0 {
+		t.Fatal("expected to run immediately")
+	}
+	if _, err := d.Delay(notAfter); err != ErrWindowExpired {
+		t.Fatal("expected the window to be expired")
+	}
+	if delay, err := (&Descriptor{}).Delay(notAfter); err != nil || delay != 0 {
+		t.Fatal("expected no window")
+	}
+}
+
+func TestParseInvalidWindow(t *testing.T) {
+	_, err := Parse([]byte(`{"nettests": [{"test_name": "telegram"}],
+"not_before": "2020-07-01T13:00:00Z", "not_after": "2020-07-01T12:00:00Z"}`))
+	if err == nil {
+		t.Fatal("expected an error")
+	}
+}
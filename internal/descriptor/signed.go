@@ -0,0 +1,111 @@
+package descriptor
+
+import (
+	"context"
+	"crypto/ed25519"
+	"encoding/json"
+	"io"
+	"io/ioutil"
+	"net/http"
+	"strings"
+
+	"github.com/pkg/errors"
+)
+
+// ErrInvalidSignature indicates that a signed descriptor has an
+// invalid signature and must therefore not be run.
+var ErrInvalidSignature = errors.New("descriptor: invalid signature")
+
+// Signed is a descriptor signed by its publisher. The Payload is the
+// serialized descriptor and the Signature is the ed25519 signature of
+// the Payload made using the publisher's private key.
+type Signed struct {
+	Payload   []byte `json:"payload"`
+	PublicKey []byte `json:"public_key"`
+	Signature []byte `json:"signature"`
+}
+
+// Sign signs the given serialized descriptor.
+func Sign(payload []byte, key ed25519.PrivateKey) *Signed {
+	return &Signed{
+		Payload:   payload,
+		PublicKey: key.Public().(ed25519.PublicKey),
+		Signature: ed25519.Sign(key, payload),
+	}
+}
+
+// Verify checks the signature and returns the parsed descriptor.
+func (s *Signed) Verify() (*Descriptor, error) {
+	if err := s.verifySignature(); err != nil {
+		return nil, err
+	}
+	return Parse(s.Payload)
+}
+
+func (s *Signed) verifySignature() error {
+	if len(s.PublicKey) != ed25519.PublicKeySize {
+		return ErrInvalidSignature
+	}
+	if !ed25519.Verify(s.PublicKey, s.Payload, s.Signature) {
+		return ErrInvalidSignature
+	}
+	return nil
+}
+
+// Open reads a descriptor from the given file. The descriptor may be either
+// plain or signed. When it is signed, we verify the signature and we return
+// the publisher if it is trusted. When it is unsigned or signed by an unknown
+// publisher, the returned publisher is nil and the caller should decide
+// what to do according to the configured policy.
+func Open(path string, ts *TrustStore) (*Descriptor, *Publisher, error) {
+	data, err := ioutil.ReadFile(path)
+	if err != nil {
+		return nil, nil, err
+	}
+	return open(data, ts)
+}
+
+// maxFetchSize is the maximum size of a descriptor we are willing to fetch.
+const maxFetchSize = 1 << 20
+
+// Fetch is like Open but fetches the descriptor from the given HTTPS URL,
+// which allows probes to share the descriptor of a coordinated campaign.
+func Fetch(ctx context.Context, client *http.Client, URL string, ts *TrustStore) (*Descriptor, *Publisher, error) {
+	if !strings.HasPrefix(URL, "https://") {
+		return nil, nil, errors.New("descriptor URL must use https")
+	}
+	req, err := http.NewRequest("GET", URL, nil)
+	if err != nil {
+		return nil, nil, err
+	}
+	resp, err := client.Do(req.WithContext(ctx))
+	if err != nil {
+		return nil, nil, errors.Wrap(err, "fetching descriptor")
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != 200 {
+		return nil, nil, errors.Errorf("fetching descriptor: %s", resp.Status)
+	}
+	data, err := ioutil.ReadAll(io.LimitReader(resp.Body, maxFetchSize))
+	if err != nil {
+		return nil, nil, errors.Wrap(err, "reading descriptor")
+	}
+	return open(data, ts)
+}
+
+func open(data []byte, ts *TrustStore) (*Descriptor, *Publisher, error) {
+	var signed Signed
+	if err := json.Unmarshal(data, &signed); err != nil || signed.Payload == nil {
+		d, err := Parse(data)
+		return d, nil, err
+	}
+	d, err := signed.Verify()
+	if err != nil {
+		return nil, nil, err
+	}
+	publisher, err := ts.lookup(signed.PublicKey)
+	if err != nil {
+		return nil, nil, err
+	}
+	return d, publisher, nil
+}
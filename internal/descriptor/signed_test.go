@@ -0,0 +1,188 @@
+package descriptor
+
+import (
+	"context"
+	"crypto/ed25519"
+	"encoding/json"
+	"errors"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+type memkvstore struct {
+	m map[string][]byte
+}
+
+func (kvs *memkvstore) Get(key string) ([]byte, error) {
+	value, found := kvs.m[key]
+	if !found {
+		return nil, errors.New("no such key")
+	}
+	return value, nil
+}
+
+func (kvs *memkvstore) Set(key string, value []byte) error {
+	kvs.m[key] = value
+	return nil
+}
+
+func writeSigned(t *testing.T, dir string, signed *Signed) string {
+	data, err := json.Marshal(signed)
+	if err != nil {
+		t.Fatal(err)
+	}
+	path := filepath.Join(dir, "signed.json")
+	if err := ioutil.WriteFile(path, data, 0600); err != nil {
+		t.Fatal(err)
+	}
+	return path
+}
+
+func TestOpenSignedDescriptor(t *testing.T) {
+	dir, err := ioutil.TempDir("", "ooniprobe-descriptor")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+	payload, err := ioutil.ReadFile("testdata/valid-descriptor.json")
+	if err != nil {
+		t.Fatal(err)
+	}
+	pub, priv, err := ed25519.GenerateKey(nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	path := writeSigned(t, dir, Sign(payload, priv))
+	ts := NewTrustStore(&memkvstore{m: make(map[string][]byte)})
+
+	d, publisher, err := Open(path, ts)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if d == nil || publisher != nil {
+		t.Fatal("expected an unknown publisher")
+	}
+
+	if err := ts.Add("OONI", pub); err != nil {
+		t.Fatal(err)
+	}
+	d, publisher, err = Open(path, ts)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if d == nil || publisher == nil || publisher.Name != "OONI" {
+		t.Fatal("expected a trusted publisher")
+	}
+
+	if err := ts.Remove("OONI"); err != nil {
+		t.Fatal(err)
+	}
+	if publishers, _ := ts.List(); len(publishers) != 0 {
+		t.Fatal("expected no publishers")
+	}
+}
+
+func TestOpenTamperedDescriptor(t *testing.T) {
+	dir, err := ioutil.TempDir("", "ooniprobe-descriptor")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+	_, priv, err := ed25519.GenerateKey(nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	signed := Sign([]byte(`{"nettests":[{"test_name":"telegram"}]}`), priv)
+	signed.Payload = []byte(`{"nettests":[{"test_name":"whatsapp"}]}`)
+	path := writeSigned(t, dir, signed)
+	ts := NewTrustStore(&memkvstore{m: make(map[string][]byte)})
+	if _, _, err := Open(path, ts); !errors.Is(err, ErrInvalidSignature) {
+		t.Fatalf("not the error we expected: %+v", err)
+	}
+}
+
+func TestOpenUnsignedDescriptor(t *testing.T) {
+	ts := NewTrustStore(&memkvstore{m: make(map[string][]byte)})
+	d, publisher, err := Open("testdata/valid-descriptor.json", ts)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if d == nil || publisher != nil {
+		t.Fatal("expected an unsigned descriptor")
+	}
+}
+
+func TestTrustStoreAddInvalid(t *testing.T) {
+	ts := NewTrustStore(&memkvstore{m: make(map[string][]byte)})
+	if err := ts.Add("", make([]byte, ed25519.PublicKeySize)); err == nil {
+		t.Fatal("expected an error")
+	}
+	if err := ts.Add("OONI", []byte("antani")); err == nil {
+		t.Fatal("expected an error")
+	}
+}
+
+func TestFetchDescriptor(t *testing.T) {
+	payload, err := ioutil.ReadFile("testdata/valid-descriptor.json")
+	if err != nil {
+		t.Fatal(err)
+	}
+	srv := httptest.NewTLSServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/campaign.json" {
+			w.WriteHeader(404)
+			return
+		}
+		w.Write(payload)
+	}))
+	defer srv.Close()
+	ts := NewTrustStore(&memkvstore{m: make(map[string][]byte)})
+	ctx := context.Background()
+
+	d, publisher, err := Fetch(ctx, srv.Client(), srv.URL+"/campaign.json", ts)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if d == nil || publisher != nil {
+		t.Fatal("expected an unsigned descriptor")
+	}
+	if _, _, err := Fetch(ctx, srv.Client(), srv.URL+"/nonexistent.json", ts); err == nil {
+		t.Fatal("expected an error")
+	}
+	if _, _, err := Fetch(ctx, srv.Client(), "http://www.example.com/", ts); err == nil {
+		t.Fatal("expected an error")
+	}
+}
+
+func TestTrustStorePublisher(t *testing.T) {
+	pub, priv, err := ed25519.GenerateKey(nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	signed := Sign([]byte(`{"antani":"4.2"}`), priv)
+	ts := NewTrustStore(&memkvstore{m: make(map[string][]byte)})
+	publisher, err := ts.Publisher(signed)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if publisher != nil {
+		t.Fatal("expected no publisher before trusting the key")
+	}
+	if err := ts.Add("antani", pub); err != nil {
+		t.Fatal(err)
+	}
+	publisher, err = ts.Publisher(signed)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if publisher == nil || publisher.Name != "antani" {
+		t.Fatalf("unexpected publisher: %+v", publisher)
+	}
+	signed.Payload = []byte(`{"antani":"4.3"}`)
+	if _, err := ts.Publisher(signed); !errors.Is(err, ErrInvalidSignature) {
+		t.Fatalf("not the error we expected: %+v", err)
+	}
+}
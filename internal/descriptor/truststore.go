@@ -0,0 +1,117 @@
+package descriptor
+
+import (
+	"bytes"
+	"crypto/ed25519"
+	"encoding/json"
+
+	"github.com/pkg/errors"
+)
+
+// trustStoreKey is the KVStore key containing the trust store.
+const trustStoreKey = "descriptor_publishers.state"
+
+// KVStore is a generic key-value store. The engine's
+// FileSystemKVStore implements this interface.
+type KVStore interface {
+	Get(key string) ([]byte, error)
+	Set(key string, value []byte) error
+}
+
+// Publisher is a trusted publisher of descriptors.
+type Publisher struct {
+	Name      string `json:"name"`
+	PublicKey []byte `json:"public_key"`
+}
+
+// TrustStore contains the publishers trusted by the user.
+type TrustStore struct {
+	kvstore KVStore
+}
+
+// NewTrustStore creates a new TrustStore using the given KVStore.
+func NewTrustStore(kvstore KVStore) *TrustStore {
+	return &TrustStore{kvstore: kvstore}
+}
+
+// List returns the trusted publishers.
+func (ts *TrustStore) List() ([]Publisher, error) {
+	data, err := ts.kvstore.Get(trustStoreKey)
+	if err != nil {
+		// The KVStore fails when the key does not exist, which
+		// means that we do not trust any publisher yet.
+		return nil, nil
+	}
+	var publishers []Publisher
+	if err := json.Unmarshal(data, &publishers); err != nil {
+		return nil, errors.Wrap(err, "parsing trust store")
+	}
+	return publishers, nil
+}
+
+// Publisher checks the signature of s, which may sign any payload, and
+// returns the trusted publisher that made it. The returned publisher is nil
+// when the signature is valid but the signer is not trusted.
+func (ts *TrustStore) Publisher(s *Signed) (*Publisher, error) {
+	if err := s.verifySignature(); err != nil {
+		return nil, err
+	}
+	return ts.lookup(s.PublicKey)
+}
+
+func (ts *TrustStore) lookup(publicKey []byte) (*Publisher, error) {
+	publishers, err := ts.List()
+	if err != nil {
+		return nil, err
+	}
+	for _, p := range publishers {
+		if bytes.Equal(p.PublicKey, publicKey) {
+			return &p, nil
+		}
+	}
+	return nil, nil
+}
+
+// Add adds or replaces a trusted publisher.
+func (ts *TrustStore) Add(name string, publicKey []byte) error {
+	if name == "" {
+		return errors.New("empty publisher name")
+	}
+	if len(publicKey) != ed25519.PublicKeySize {
+		return errors.New("invalid ed25519 public key")
+	}
+	publishers, err := ts.List()
+	if err != nil {
+		return err
+	}
+	publishers = removePublisher(publishers, name)
+	publishers = append(publishers, Publisher{Name: name, PublicKey: publicKey})
+	return ts.write(publishers)
+}
+
+// Remove removes the publisher with the given name.
+func (ts *TrustStore) Remove(name string) error {
+	publishers, err := ts.List()
+	if err != nil {
+		return err
+	}
+	return ts.write(removePublisher(publishers, name))
+}
+
+func (ts *TrustStore) write(publishers []Publisher) error {
+	data, err := json.Marshal(publishers)
+	if err != nil {
+		return err
+	}
+	return ts.kvstore.Set(trustStoreKey, data)
+}
+
+func removePublisher(publishers []Publisher, name string) []Publisher {
+	var out []Publisher
+	for _, p := range publishers {
+		if p.Name != name {
+			out = append(out, p)
+		}
+	}
+	return out
+}
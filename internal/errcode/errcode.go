@@ -0,0 +1,110 @@
+// Package errcode contains the taxonomy of error codes that we emit
+// along with Go errors, so that frontends can show actionable messages
+// to users rather than parsing raw error strings.
+package errcode
+
+import (
+	"errors"
+	"net"
+	"syscall"
+
+	"github.com/ooni/probe-cli/internal/i18n"
+)
+
+// Code is an error code.
+type Code string
+
+const (
+	// Unknown is the code of errors that we cannot classify.
+	Unknown = Code("unknown")
+
+	// NoInternet indicates that we're not connected to the internet.
+	NoInternet = Code("no_internet")
+
+	// DNSFailed indicates that the DNS resolver timed out or failed
+	// temporarily, which does not mean that we are offline.
+	DNSFailed = Code("dns_failed")
+
+	// BackendUnreachable indicates we cannot reach the OONI backends.
+	BackendUnreachable = Code("backend_unreachable")
+
+	// GeolocationFailed indicates we could not geolocate the probe.
+	GeolocationFailed = Code("geolocation_failed")
+
+	// AllTunnelsFailed indicates that all circumvention tunnels failed.
+	AllTunnelsFailed = Code("all_tunnels_failed")
+
+	// DiskFull indicates that there is no space left on the device.
+	DiskFull = Code("disk_full")
+)
+
+// Error is an error with an attached Code.
+type Error struct {
+	Code Code
+	Err  error
+}
+
+// Error implements error.Error.
+func (e *Error) Error() string {
+	return e.Err.Error()
+}
+
+// Unwrap returns the wrapped error.
+func (e *Error) Unwrap() error {
+	return e.Err
+}
+
+// New attaches the given code to the given error. If the error is nil
+// this function returns nil. If err is more specific than the given code
+// (e.g., we ran out of disk space) we keep the more specific code.
+func New(code Code, err error) error {
+	if err == nil {
+		return nil
+	}
+	if specific := classify(err); specific != Unknown {
+		code = specific
+	}
+	return &Error{Code: code, Err: err}
+}
+
+// Of returns the code associated with the given error. If the error has
+// not been explicitly tagged, we try to infer its code.
+func Of(err error) Code {
+	if err == nil {
+		return ""
+	}
+	var e *Error
+	if errors.As(err, &e) {
+		return e.Code
+	}
+	return classify(err)
+}
+
+func classify(err error) Code {
+	if errors.Is(err, syscall.ENOSPC) {
+		return DiskFull
+	}
+	if errors.Is(err, syscall.ENETUNREACH) {
+		return NoInternet
+	}
+	var dnsErr *net.DNSError
+	if errors.As(err, &dnsErr) {
+		switch {
+		case dnsErr.IsNotFound:
+			return Unknown
+		case dnsErr.IsTimeout || dnsErr.IsTemporary:
+			return DNSFailed
+		default:
+			return NoInternet
+		}
+	}
+	return Unknown
+}
+
+// Message returns the user-facing message describing the code.
+func (c Code) Message() i18n.Message {
+	if c == "" {
+		c = Unknown
+	}
+	return i18n.New("errcode." + string(c))
+}
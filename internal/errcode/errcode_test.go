@@ -0,0 +1,68 @@
+package errcode
+
+import (
+	"errors"
+	"fmt"
+	"net"
+	"os"
+	"syscall"
+	"testing"
+)
+
+func TestNewWithNilError(t *testing.T) {
+	if New(GeolocationFailed, nil) != nil {
+		t.Fatal("expected nil")
+	}
+	if Of(nil) != "" {
+		t.Fatal("expected empty code")
+	}
+}
+
+func TestOfTaggedError(t *testing.T) {
+	expected := errors.New("mocked error")
+	err := fmt.Errorf("wrapped: %w", New(BackendUnreachable, expected))
+	if Of(err) != BackendUnreachable {
+		t.Fatal("unexpected code")
+	}
+	if !errors.Is(err, expected) {
+		t.Fatal("cannot unwrap the original error")
+	}
+}
+
+func TestOfDiskFull(t *testing.T) {
+	err := &os.PathError{Op: "write", Path: "/tmp/x", Err: syscall.ENOSPC}
+	if Of(err) != DiskFull {
+		t.Fatal("expected DiskFull")
+	}
+	if Of(New(GeolocationFailed, err)) != DiskFull {
+		t.Fatal("expected the more specific code to win")
+	}
+}
+
+func TestOfNoInternet(t *testing.T) {
+	err := &net.DNSError{Err: "no route", Name: "api.ooni.io"}
+	if Of(err) != NoInternet {
+		t.Fatal("expected NoInternet")
+	}
+}
+
+func TestOfDNSFailed(t *testing.T) {
+	timeout := &net.DNSError{Err: "i/o timeout", Name: "api.ooni.io", IsTimeout: true}
+	if Of(timeout) != DNSFailed {
+		t.Fatal("expected DNSFailed for a timeout")
+	}
+	temporary := &net.DNSError{Err: "server misbehaving", Name: "api.ooni.io", IsTemporary: true}
+	if Of(temporary) != DNSFailed {
+		t.Fatal("expected DNSFailed for a temporary failure")
+	}
+	notFound := &net.DNSError{Err: "no such host", Name: "api.ooni.io", IsNotFound: true}
+	if Of(notFound) != Unknown {
+		t.Fatal("expected Unknown for a nonexistent domain")
+	}
+}
+
+func TestOfUnknown(t *testing.T) {
+	if Of(errors.New("mocked error")) != Unknown {
+		t.Fatal("expected Unknown")
+	}
+}
@@ -0,0 +1,72 @@
+// Package i18n contains the user-facing messages produced by ooniprobe.
+//
+// Rather than emitting English strings, code that produces user-facing
+// text should emit a Message containing a stable ID and parameters, such
+// that frontends can localize the message without parsing strings. The
+// English rendering of each message is only provided as a fallback.
+package i18n
+
+import "strings"
+
+// Message is a localizable user-facing message.
+type Message struct {
+	// ID is the stable message identifier.
+	ID string `json:"id"`
+
+	// Params contains the message parameters.
+	Params map[string]string `json:"params,omitempty"`
+}
+
+// New creates a new Message with the given ID and parameters. The
+// params are key, value pairs; a trailing key without value is ignored.
+func New(id string, params ...string) Message {
+	m := Message{ID: id}
+	for i := 0; i+1 < len(params); i += 2 {
+		if m.Params == nil {
+			m.Params = make(map[string]string)
+		}
+		m.Params[params[i]] = params[i+1]
+	}
+	return m
+}
+
+// String returns the English rendering of the message. We replace each
+// {name} placeholder with the value of the corresponding parameter. If the
+// message ID is unknown, we return the ID itself.
+func (m Message) String() string {
+	text, found := english[m.ID]
+	if !found {
+		return m.ID
+	}
+	for key, value := range m.Params {
+		text = strings.Replace(text, "{"+key+"}", value, -1)
+	}
+	return text
+}
+
+// english contains the English rendering of every message ID.
+var english = map[string]string{
+	"errcode.unknown":             "An unexpected error occurred.",
+	"errcode.no_internet":         "You do not seem to be connected to the internet.",
+	"errcode.dns_failed":          "Your DNS resolver is not responding. Try again later or use another resolver.",
+	"errcode.backend_unreachable": "Cannot reach the OONI servers. Try again later or enable circumvention.",
+	"errcode.geolocation_failed":  "Cannot determine your network location. Try again later.",
+	"errcode.all_tunnels_failed":  "All the configured circumvention tunnels failed to start.",
+	"errcode.disk_full":           "There is not enough disk space left to save measurements.",
+
+	"nettests.do_not_measure_skipped": "Skipping {count} URLs in the do-not-measure list",
+
+	"onboard.test_sensitive_categories": "Do you want to test sensitive website categories (e.g., {categories})?",
+
+	"verdict.ok":                            "No sign of blocking.",
+	"verdict.anomaly":                       "Something unusual happened, which may or may not be blocking.",
+	"verdict.unknown":                       "We cannot tell what happened.",
+	"verdict.im.ok":                         "{app} appears to work on this network.",
+	"verdict.im.blocked":                    "{app} is likely blocked on this network.",
+	"verdict.web_connectivity.ok":           "The website is accessible.",
+	"verdict.web_connectivity.down":         "The website seems to be down for everyone, not only for you.",
+	"verdict.web_connectivity.dns":          "Likely DNS-based blocking by your network's resolver.",
+	"verdict.web_connectivity.tcp_ip":       "Likely IP-based blocking: connecting to the website failed.",
+	"verdict.web_connectivity.http_failure": "Likely blocking: the connection to the website was interrupted.",
+	"verdict.web_connectivity.http_diff":    "The page differs from what others see, which may indicate a block page.",
+}
@@ -0,0 +1,23 @@
+package i18n
+
+import "testing"
+
+func TestMessageString(t *testing.T) {
+	m := New("nettests.do_not_measure_skipped", "count", "17")
+	if m.String() != "Skipping 17 URLs in the do-not-measure list" {
+		t.Fatalf("unexpected string: %s", m.String())
+	}
+	if m.Params["count"] != "17" {
+		t.Fatal("unexpected params")
+	}
+}
+
+func TestMessageStringUnknownID(t *testing.T) {
+	m := New("antani.mascetti", "count")
+	if m.String() != "antani.mascetti" {
+		t.Fatal("expected the ID")
+	}
+	if m.Params != nil {
+		t.Fatal("expected nil params")
+	}
+}
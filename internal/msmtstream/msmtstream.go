@@ -0,0 +1,35 @@
+// Package msmtstream reads measurement files in a streaming fashion.
+// Measurement files may contain a single measurement, as written by
+// ooniprobe, or many newline separated measurements, as in legacy
+// reports. Some of these files are hundreds of MB, so we read them
+// one measurement at a time rather than loading them in memory.
+package msmtstream
+
+import (
+	"bufio"
+	"encoding/json"
+	"io"
+)
+
+// Reader reads measurements one at a time.
+type Reader struct {
+	decoder *json.Decoder
+}
+
+// NewReader creates a new Reader reading from r.
+func NewReader(r io.Reader) *Reader {
+	decoder := json.NewDecoder(bufio.NewReader(r))
+	decoder.UseNumber()
+	return &Reader{decoder: decoder}
+}
+
+// Next returns the next measurement or io.EOF when done. Only the
+// current measurement is kept in memory, hence the memory usage is
+// bounded by the size of the largest measurement in the file.
+func (r *Reader) Next() (json.RawMessage, error) {
+	var raw json.RawMessage
+	if err := r.decoder.Decode(&raw); err != nil {
+		return nil, err
+	}
+	return raw, nil
+}
@@ -0,0 +1,37 @@
+package msmtstream
+
+import (
+	"encoding/json"
+	"errors"
+	"io"
+	"strings"
+	"testing"
+)
+
+const measurement = `{
+  "report_id": "20201201T100000Z_example_IT_30722_n1_abc",
+  "test_keys": {"queries": [{"answers": [1, 2.5, "x", null, true]}], "empty": {}, "list": []},
+  "test_name": "example",
+  "input": null
+}`
+
+func TestReaderMultipleMeasurements(t *testing.T) {
+	r := NewReader(strings.NewReader(measurement + "\n" + measurement + "\n"))
+	var count int
+	for {
+		raw, err := r.Next()
+		if errors.Is(err, io.EOF) {
+			break
+		}
+		if err != nil {
+			t.Fatal(err)
+		}
+		if !json.Valid(raw) {
+			t.Fatal("invalid JSON")
+		}
+		count++
+	}
+	if count != 2 {
+		t.Fatal("unexpected number of measurements")
+	}
+}
@@ -0,0 +1,129 @@
+// Package msmttrim trims measurements that are too large to be accepted
+// by the collector. Rather than failing the submission, we remove the
+// largest string fields according to the following policy:
+//
+// 1. response bodies (i.e., any field below a "body" key), largest first;
+//
+// 2. any other string inside the test keys, largest first.
+//
+// We stop as soon as the measurement is small enough. The metadata of the
+// measurement is never modified, except for adding Annotation.
+package msmttrim
+
+import (
+	"encoding/json"
+	"sort"
+	"strconv"
+
+	"github.com/ooni/probe-engine/model"
+	"github.com/pkg/errors"
+)
+
+// MaxSize is the size in bytes above which we trim measurements.
+const MaxSize = 1 << 22
+
+// Annotation is the annotation containing the number of trimmed fields.
+const Annotation = "trimmed_fields"
+
+// ErrTooLarge indicates that the measurement is still too large after
+// we have trimmed every string field we are allowed to trim.
+var ErrTooLarge = errors.New("msmttrim: measurement too large")
+
+// leaf is a string field inside the test keys.
+type leaf struct {
+	path   string
+	isBody bool
+	size   int
+	clear  func()
+}
+
+// collect returns all the string fields inside value.
+func collect(value interface{}, path string, isBody bool, out *[]leaf) {
+	switch v := value.(type) {
+	case map[string]interface{}:
+		for key, child := range v {
+			if s, ok := child.(string); ok {
+				m, key := v, key
+				*out = append(*out, leaf{
+					path:   path + "." + key,
+					isBody: isBody || key == "body",
+					size:   len(s),
+					clear:  func() { m[key] = "" },
+				})
+				continue
+			}
+			collect(child, path+"."+key, isBody || key == "body", out)
+		}
+	case []interface{}:
+		for idx, child := range v {
+			childPath := path + "[" + strconv.Itoa(idx) + "]"
+			if s, ok := child.(string); ok {
+				a, idx := v, idx
+				*out = append(*out, leaf{
+					path:   childPath,
+					isBody: isBody,
+					size:   len(s),
+					clear:  func() { a[idx] = "" },
+				})
+				continue
+			}
+			collect(child, childPath, isBody, out)
+		}
+	}
+}
+
+// Trim returns m unchanged if its serialization is not larger than limit.
+// Otherwise, it returns a copy of m with trimmed test keys, along with the
+// list of trimmed fields. The original measurement is never modified, so
+// the caller can still save the complete measurement locally.
+func Trim(m *model.Measurement, limit int) (*model.Measurement, []string, error) {
+	data, err := json.Marshal(m)
+	if err != nil {
+		return nil, nil, err
+	}
+	if len(data) <= limit {
+		return m, nil, nil
+	}
+	tkdata, err := json.Marshal(m.TestKeys)
+	if err != nil {
+		return nil, nil, err
+	}
+	var tk interface{}
+	if err := json.Unmarshal(tkdata, &tk); err != nil {
+		return nil, nil, err
+	}
+	var leaves []leaf
+	collect(tk, "test_keys", false, &leaves)
+	sort.SliceStable(leaves, func(i, j int) bool {
+		if leaves[i].isBody != leaves[j].isBody {
+			return leaves[i].isBody
+		}
+		return leaves[i].size > leaves[j].size
+	})
+	// Leave some room for the annotation we are going to add.
+	excess := len(data) - limit + 64
+	var trimmed []string
+	for _, l := range leaves {
+		if excess <= 0 || l.size <= 0 {
+			break
+		}
+		l.clear()
+		excess -= l.size
+		trimmed = append(trimmed, l.path)
+	}
+	out := *m
+	out.TestKeys = tk
+	out.Annotations = make(map[string]string)
+	for key, value := range m.Annotations {
+		out.Annotations[key] = value
+	}
+	out.AddAnnotation(Annotation, strconv.Itoa(len(trimmed)))
+	data, err = json.Marshal(&out)
+	if err != nil {
+		return nil, nil, err
+	}
+	if len(data) > limit {
+		return nil, nil, ErrTooLarge
+	}
+	return &out, trimmed, nil
+}
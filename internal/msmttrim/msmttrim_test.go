@@ -0,0 +1,70 @@
+package msmttrim
+
+import (
+	"encoding/json"
+	"strings"
+	"testing"
+
+	"github.com/ooni/probe-engine/model"
+)
+
+func newMeasurement() *model.Measurement {
+	return &model.Measurement{
+		TestName: "web_connectivity",
+		TestKeys: map[string]interface{}{
+			"requests": []interface{}{
+				map[string]interface{}{
+					"response": map[string]interface{}{
+						"body": strings.Repeat("b", 1000),
+					},
+				},
+			},
+			"queries": []interface{}{strings.Repeat("q", 600)},
+		},
+	}
+}
+
+func TestTrimSmallMeasurement(t *testing.T) {
+	m := newMeasurement()
+	out, trimmed, err := Trim(m, 1<<20)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if out != m || len(trimmed) != 0 {
+		t.Fatal("expected the measurement to be unchanged")
+	}
+}
+
+func TestTrimBodiesFirst(t *testing.T) {
+	m := newMeasurement()
+	out, trimmed, err := Trim(m, 1000)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(trimmed) != 1 || trimmed[0] != "test_keys.requests[0].response.body" {
+		t.Fatalf("unexpected trimmed fields: %+v", trimmed)
+	}
+	if out.Annotations[Annotation] != "1" {
+		t.Fatal("missing annotation")
+	}
+	data, err := json.Marshal(out)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(data) > 1000 || !strings.Contains(string(data), "qqqq") {
+		t.Fatal("unexpected trimmed measurement")
+	}
+	data, err = json.Marshal(m)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !strings.Contains(string(data), "bbbb") || m.Annotations != nil {
+		t.Fatal("the original measurement was modified")
+	}
+}
+
+func TestTrimTooLarge(t *testing.T) {
+	if _, _, err := Trim(newMeasurement(), 10); err != ErrTooLarge {
+		t.Fatalf("not the error we expected: %+v", err)
+	}
+}
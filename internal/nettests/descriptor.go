@@ -0,0 +1,126 @@
+package nettests
+
+import (
+	"strconv"
+
+	"github.com/apex/log"
+	"github.com/ooni/probe-cli/internal/database"
+	"github.com/ooni/probe-cli/internal/descriptor"
+	"github.com/ooni/probe-cli/internal/i18n"
+	"github.com/ooni/probe-engine/model"
+	"github.com/pkg/errors"
+)
+
+// DescriptorGroupName is the name of the group we use for the
+// results of running a run descriptor.
+const DescriptorGroupName = "descriptor"
+
+// DescriptorNettest runs a nettest described by a run descriptor.
+type DescriptorNettest struct {
+	Nettest descriptor.Nettest
+}
+
+// Run starts the test
+func (n DescriptorNettest) Run(ctl *Controller) error {
+	builder, err := ctl.Session.NewExperimentBuilder(n.Nettest.TestName)
+	if err != nil {
+		return err
+	}
+	for key, value := range n.Nettest.Annotations {
+		ctl.AddAnnotation(key, value)
+	}
+	if len(n.Nettest.Inputs) <= 0 {
+		return ctl.Run(builder, []string{""})
+	}
+	var inputs []string
+	annotations := make(map[int]map[string]string)
+	outcomes := make(map[int]string)
+	urlIDMap := make(map[int64]int64)
+	allowPrivate := ctl.Probe.Config().Advanced.AllowPrivateTargets
+	allowed, skipped := filterDescriptorInputs(n.Nettest.Inputs, ctl.doNotMeasureList(), allowPrivate)
+	if skipped.doNotMeasure > 0 {
+		msg := i18n.New(
+			"nettests.do_not_measure_skipped", "count", strconv.Itoa(skipped.doNotMeasure))
+		log.WithField("message_id", msg.ID).Info(msg.String())
+	}
+	ctl.AddAnnotation(doNotMeasureAnnotation, strconv.Itoa(skipped.doNotMeasure))
+	if !allowPrivate {
+		ctl.AddAnnotation(privateTargetAnnotation, strconv.Itoa(skipped.private))
+	}
+	for _, input := range allowed {
+		idx := len(inputs)
+		inputs = append(inputs, input.Input)
+		annotations[idx] = input.Annotations
+		if input.ExpectedOutcome != "" {
+			outcomes[idx] = input.ExpectedOutcome
+		}
+		if n.Nettest.TestName != "web_connectivity" {
+			continue
+		}
+		var urlID int64
+		err := ctl.locked(func() (err error) {
+			urlID, err = database.CreateOrUpdateURL(
+				ctl.Probe.DB(), input.Input, descriptorCategoryCode, "XX",
+			)
+			return
+		})
+		if err != nil {
+			return err
+		}
+		urlIDMap[int64(idx)] = urlID
+	}
+	if len(inputs) <= 0 {
+		return errors.New("no inputs left to measure")
+	}
+	if err := pinInputs(ctl, n.Nettest.TestName, inputs); err != nil {
+		return err
+	}
+	if len(urlIDMap) > 0 {
+		ctl.SetInputIdxMap(urlIDMap)
+	}
+	ctl.SetInputAnnotations(annotations)
+	ctl.SetExpectedOutcomes(outcomes)
+	return ctl.Run(builder, inputs)
+}
+
+// descriptorCategoryCode is the category code of descriptor inputs.
+const descriptorCategoryCode = "MISC"
+
+// skippedInputs counts the inputs we skipped for each reason.
+type skippedInputs struct {
+	doNotMeasure int
+	private      int
+}
+
+// filterDescriptorInputs returns the descriptor inputs we are allowed to
+// measure, applying the same filters we apply to the test lists.
+func filterDescriptorInputs(in []descriptor.Input, dnm doNotMeasureList, allowPrivate bool) ([]descriptor.Input, skippedInputs) {
+	var (
+		out     []descriptor.Input
+		skipped skippedInputs
+	)
+	for _, input := range in {
+		info := model.URLInfo{URL: input.Input, CategoryCode: descriptorCategoryCode}
+		if dnm.matches(info) {
+			log.Debugf("Skipping %s because it is in the do-not-measure list", input.Input)
+			skipped.doNotMeasure++
+			continue
+		}
+		if !allowPrivate && isPrivateTarget(input.Input) {
+			log.Warnf("Skipping %s because it points into private address space", input.Input)
+			skipped.private++
+			continue
+		}
+		out = append(out, input)
+	}
+	return out, skipped
+}
+
+// descriptorGroup returns the Group corresponding to a descriptor.
+func descriptorGroup(d *descriptor.Descriptor) Group {
+	group := Group{Label: d.Name}
+	for _, nt := range d.Nettests {
+		group.Nettests = append(group.Nettests, DescriptorNettest{Nettest: nt})
+	}
+	return group
+}
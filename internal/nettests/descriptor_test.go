@@ -0,0 +1,62 @@
+package nettests
+
+import (
+	"testing"
+
+	"github.com/ooni/probe-cli/internal/database"
+	"github.com/ooni/probe-cli/internal/descriptor"
+)
+
+func TestFilterDescriptorInputs(t *testing.T) {
+	in := []descriptor.Input{
+		{Input: "https://www.example.com/"},
+		{Input: "http://192.168.1.1/"},
+		{Input: "https://ooni.org/"},
+	}
+	dnm := doNotMeasureList{Domains: []string{"example.com"}}
+	out, skipped := filterDescriptorInputs(in, dnm, false)
+	if len(out) != 1 || out[0].Input != "https://ooni.org/" {
+		t.Fatalf("unexpected inputs: %+v", out)
+	}
+	if skipped.doNotMeasure != 1 || skipped.private != 1 {
+		t.Fatalf("unexpected skipped inputs: %+v", skipped)
+	}
+	out, skipped = filterDescriptorInputs(in, dnm, true)
+	if len(out) != 2 || skipped.private != 0 {
+		t.Fatalf("unexpected inputs: %+v", out)
+	}
+	out, _ = filterDescriptorInputs(in, doNotMeasureList{
+		CategoryCodes: []string{descriptorCategoryCode}}, true)
+	if len(out) != 0 {
+		t.Fatal("expected the category code to apply to descriptor inputs")
+	}
+}
+
+func TestDescriptorNettestDoNotMeasure(t *testing.T) {
+	probe := newOONIProbe(t)
+	probe.Config().Nettests.DoNotMeasureDomains = []string{"example.com"}
+	sess, err := probe.NewSession()
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer sess.Close()
+	network, err := database.CreateNetwork(probe.DB(), sess)
+	if err != nil {
+		t.Fatal(err)
+	}
+	res, err := database.CreateResult(probe.DB(), probe.Home(), DescriptorGroupName, network.ID)
+	if err != nil {
+		t.Fatal(err)
+	}
+	nt := DescriptorNettest{Nettest: descriptor.Nettest{
+		TestName: "web_connectivity",
+		Inputs:   []descriptor.Input{{Input: "https://www.example.com/"}},
+	}}
+	ctl := NewController(nt, probe, res, sess)
+	if err := nt.Run(ctl); err == nil {
+		t.Fatal("expected an error because no inputs are left")
+	}
+	if ctl.annotations[doNotMeasureAnnotation] != "1" {
+		t.Fatalf("unexpected annotations: %+v", ctl.annotations)
+	}
+}
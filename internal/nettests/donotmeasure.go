@@ -0,0 +1,65 @@
+package nettests
+
+import (
+	"net/url"
+	"strings"
+
+	"github.com/ooni/probe-engine/model"
+)
+
+// doNotMeasureAnnotation is the annotation containing the number of
+// inputs skipped because they were in the do-not-measure list.
+const doNotMeasureAnnotation = "do_not_measure_skipped"
+
+// doNotMeasureList is the user-managed list of domains and categories
+// that we must never measure, even if they appear in the test lists.
+type doNotMeasureList struct {
+	Domains       []string
+	CategoryCodes []string
+}
+
+// matches returns whether the given URL must not be measured.
+func (dnm doNotMeasureList) matches(info model.URLInfo) bool {
+	for _, code := range dnm.CategoryCodes {
+		if strings.EqualFold(code, info.CategoryCode) {
+			return true
+		}
+	}
+	parsed, err := url.Parse(info.URL)
+	if err != nil {
+		return false
+	}
+	hostname := strings.ToLower(parsed.Hostname())
+	for _, domain := range dnm.Domains {
+		domain = strings.ToLower(strings.Trim(domain, "."))
+		if domain == "" {
+			continue
+		}
+		if hostname == domain || strings.HasSuffix(hostname, "."+domain) {
+			return true
+		}
+	}
+	return false
+}
+
+// filter returns the URLs that we are allowed to measure along
+// with the number of URLs that we have skipped.
+func (dnm doNotMeasureList) filter(in []model.URLInfo) ([]model.URLInfo, int) {
+	var out []model.URLInfo
+	for _, info := range in {
+		if dnm.matches(info) {
+			continue
+		}
+		out = append(out, info)
+	}
+	return out, len(in) - len(out)
+}
+
+// doNotMeasureList returns the do-not-measure list configured by the user,
+// which we must apply to the inputs of every nettest.
+func (c *Controller) doNotMeasureList() doNotMeasureList {
+	return doNotMeasureList{
+		Domains:       c.Probe.Config().Nettests.DoNotMeasureDomains,
+		CategoryCodes: c.Probe.Config().Nettests.DoNotMeasureCategoryCodes,
+	}
+}
@@ -0,0 +1,43 @@
+package nettests
+
+import (
+	"testing"
+
+	"github.com/ooni/probe-engine/model"
+)
+
+func TestDoNotMeasureListFilter(t *testing.T) {
+	dnm := doNotMeasureList{
+		Domains:       []string{"example.com", ".Example.ORG."},
+		CategoryCodes: []string{"porn"},
+	}
+	in := []model.URLInfo{
+		{URL: "https://example.com/", CategoryCode: "NEWS"},
+		{URL: "https://www.example.com/", CategoryCode: "NEWS"},
+		{URL: "https://notexample.com/", CategoryCode: "NEWS"},
+		{URL: "http://www.example.org:8080/x", CategoryCode: "NEWS"},
+		{URL: "https://www.kernel.org/", CategoryCode: "PORN"},
+		{URL: "https://www.torproject.org/", CategoryCode: "ANON"},
+	}
+	out, skipped := dnm.filter(in)
+	if skipped != 4 {
+		t.Fatalf("unexpected number of skipped URLs: %d", skipped)
+	}
+	if len(out) != 2 {
+		t.Fatal("unexpected number of URLs")
+	}
+	if out[0].URL != "https://notexample.com/" {
+		t.Fatal("unexpected first URL")
+	}
+	if out[1].URL != "https://www.torproject.org/" {
+		t.Fatal("unexpected second URL")
+	}
+}
+
+func TestDoNotMeasureListEmpty(t *testing.T) {
+	in := []model.URLInfo{{URL: "https://example.com/"}}
+	out, skipped := doNotMeasureList{}.filter(in)
+	if skipped != 0 || len(out) != 1 {
+		t.Fatal("expected no URL to be skipped")
+	}
+}
@@ -0,0 +1,132 @@
+package nettests
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"io/ioutil"
+
+	"github.com/apex/log"
+	"github.com/ooni/probe-cli/internal/database"
+	"github.com/ooni/probe-cli/internal/ooni"
+	engine "github.com/ooni/probe-engine"
+	"github.com/pkg/errors"
+)
+
+// importedGroupNames maps the name of a nettest to the name of the
+// group we use for the results of its imported measurements.
+var importedGroupNames = map[string]string{
+	"dash":                           "performance",
+	"facebook_messenger":             "im",
+	"http_header_field_manipulation": "middlebox",
+	"http_invalid_request_line":      "middlebox",
+	"ndt":                            "performance",
+	"psiphon":                        "circumvention",
+	"telegram":                       "im",
+	"tor":                            "circumvention",
+	"web_connectivity":               "websites",
+	"whatsapp":                       "im",
+}
+
+// importReport stores the measurements of the given published report
+// into a new result. Returns the number of imported measurements.
+func (r *reprocessor) importReport(ctx context.Context, reportID string) (int, error) {
+	found, err := database.HasReport(r.probe.DB(), reportID)
+	if err != nil {
+		return 0, err
+	}
+	if found {
+		log.Infof("Skipping %s: already in the results database", reportID)
+		return 0, nil
+	}
+	// Use the session's client, which honours the configured proxy.
+	raw, err := fetchReport(ctx, r.sess.DefaultHTTPClient(), ooniAPIBaseURL, reportID)
+	if err != nil {
+		return 0, err
+	}
+	if len(raw) <= 0 {
+		return 0, errors.Errorf("no published measurements for %s", reportID)
+	}
+	var first publishedMeasurement
+	if err := json.Unmarshal(raw[0], &first); err != nil {
+		return 0, errors.Wrap(err, "parsing measurement")
+	}
+	network, err := database.CreateNetwork(r.probe.DB(), &first)
+	if err != nil {
+		return 0, err
+	}
+	groupName, found := importedGroupNames[first.TestName]
+	if !found {
+		groupName = first.TestName
+	}
+	result, err := database.CreateResult(
+		r.probe.DB(), r.probe.Home(), groupName, network.ID)
+	if err != nil {
+		return 0, err
+	}
+	reportIDValue := sql.NullString{String: reportID, Valid: true}
+	var count int
+	for idx, data := range raw {
+		var pm publishedMeasurement
+		if err := json.Unmarshal(data, &pm); err != nil {
+			return count, errors.Wrap(err, "parsing measurement")
+		}
+		var urlID sql.NullInt64
+		if pm.TestName == "web_connectivity" && pm.InputString() != "" {
+			id, err := database.CreateOrUpdateURL(
+				r.probe.DB(), pm.InputString(), "MISC", "XX")
+			if err != nil {
+				return count, err
+			}
+			urlID = sql.NullInt64{Int64: id, Valid: true}
+		}
+		msmt, err := database.CreateMeasurement(
+			r.probe.DB(), reportIDValue, pm.TestName, result.MeasurementDir,
+			idx, result.ID, urlID,
+		)
+		if err != nil {
+			return count, err
+		}
+		if err := ioutil.WriteFile(msmt.MeasurementFilePath.String, data, 0644); err != nil {
+			return count, errors.Wrap(err, "saving measurement on disk")
+		}
+		if err := msmt.UploadSucceeded(r.probe.DB()); err != nil {
+			return count, err
+		}
+		if err := msmt.Done(r.probe.DB()); err != nil {
+			return count, err
+		}
+		if err := r.reprocess(msmt); err != nil {
+			log.WithError(err).Warnf("cannot summarize measurement #%d", msmt.ID)
+		}
+		count++
+	}
+	return count, result.Finished(r.probe.DB())
+}
+
+// Import fetches the measurements of the given reports from the OONI API
+// and stores them into the results database, such that users can rebuild
+// their local history, e.g., after reinstalling ooniprobe. We create one
+// result for each report and skip reports we have already stored. Returns
+// the number of imported measurements.
+func Import(probe *ooni.Probe, reportIDs []string) (int, error) {
+	sess, err := probe.NewSession()
+	if err != nil {
+		return 0, err
+	}
+	defer sess.Close()
+	r := &reprocessor{
+		probe:       probe,
+		sess:        sess,
+		experiments: make(map[string]*engine.Experiment),
+	}
+	var count int
+	for _, reportID := range reportIDs {
+		n, err := r.importReport(context.Background(), reportID)
+		count += n
+		if err != nil {
+			return count, errors.Wrapf(err, "importing %s", reportID)
+		}
+	}
+	return count, nil
+}
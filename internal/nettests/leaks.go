@@ -0,0 +1,64 @@
+package nettests
+
+import (
+	"io/ioutil"
+	"runtime"
+	"time"
+)
+
+// resourceSnapshot counts the resources used by the process.
+type resourceSnapshot struct {
+	Goroutines int
+
+	// FDs is the number of open file descriptors or -1 when we
+	// cannot count them on this platform.
+	FDs int
+}
+
+// countOpenFDs returns the number of open file descriptors or -1 if we
+// cannot count them, which is the case on platforms without /proc.
+func countOpenFDs() int {
+	infos, err := ioutil.ReadDir("/proc/self/fd")
+	if err != nil {
+		return -1
+	}
+	return len(infos)
+}
+
+func takeResourceSnapshot() resourceSnapshot {
+	return resourceSnapshot{
+		Goroutines: runtime.NumGoroutine(),
+		FDs:        countOpenFDs(),
+	}
+}
+
+// leakedSince returns the resources leaked since the before snapshot.
+func (after resourceSnapshot) leakedSince(before resourceSnapshot) resourceSnapshot {
+	leaked := resourceSnapshot{Goroutines: after.Goroutines - before.Goroutines}
+	if after.FDs >= 0 && before.FDs >= 0 {
+		leaked.FDs = after.FDs - before.FDs
+	}
+	return leaked
+}
+
+// hasLeaks returns whether the snapshot contains any leaked resource.
+func (leaked resourceSnapshot) hasLeaks() bool {
+	return leaked.Goroutines > 0 || leaked.FDs > 0
+}
+
+// leakSettleTime is how long we wait for goroutines and connections
+// to be closed before concluding that they have leaked.
+const leakSettleTime = 2 * time.Second
+
+// detectLeaks returns the resources leaked since the before snapshot,
+// giving background cleanups some time to complete.
+func detectLeaks(before resourceSnapshot) resourceSnapshot {
+	deadline := time.Now().Add(leakSettleTime)
+	for {
+		leaked := takeResourceSnapshot().leakedSince(before)
+		if !leaked.hasLeaks() || time.Now().After(deadline) {
+			return leaked
+		}
+		time.Sleep(100 * time.Millisecond)
+	}
+}
@@ -0,0 +1,68 @@
+package nettests
+
+import (
+	"io/ioutil"
+	"os"
+	"sync"
+	"testing"
+	"time"
+)
+
+// leakResources deliberately leaks a goroutine and a file descriptor
+// and returns the idempotent function to release them.
+func leakResources(t *testing.T) func() {
+	fp, err := ioutil.TempFile("", "ooniprobetests")
+	if err != nil {
+		t.Fatal(err)
+	}
+	done := make(chan bool)
+	go func() {
+		<-done
+	}()
+	var once sync.Once
+	return func() {
+		once.Do(func() {
+			close(done)
+			fp.Close()
+			os.Remove(fp.Name())
+		})
+	}
+}
+
+func TestDetectLeaks(t *testing.T) {
+	before := takeResourceSnapshot()
+	if before.FDs < 0 {
+		t.Skip("cannot count open file descriptors on this platform")
+	}
+	t.Cleanup(leakResources(t))
+	// Other goroutines and file descriptors may come and go while we
+	// run, so we only check that we see at least what we leaked.
+	leaked := takeResourceSnapshot().leakedSince(before)
+	if leaked.Goroutines < 1 || leaked.FDs < 1 {
+		t.Fatalf("unexpected leaks: %+v", leaked)
+	}
+	if !leaked.hasLeaks() {
+		t.Fatal("expected leaks")
+	}
+}
+
+func TestDetectLeaksReleased(t *testing.T) {
+	if takeResourceSnapshot().FDs < 0 {
+		t.Skip("cannot count open file descriptors on this platform")
+	}
+	release := leakResources(t)
+	t.Cleanup(release)
+	leaking := takeResourceSnapshot()
+	release()
+	deadline := time.Now().Add(leakSettleTime)
+	for {
+		after := takeResourceSnapshot()
+		if after.Goroutines < leaking.Goroutines && after.FDs < leaking.FDs {
+			return
+		}
+		if time.Now().After(deadline) {
+			t.Fatalf("resources not released: %+v => %+v", leaking, after)
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+}
@@ -0,0 +1,145 @@
+package nettests
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"io"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/ooni/probe-cli/internal/enginex"
+	"github.com/ooni/probe-cli/internal/ooni"
+	"github.com/ooni/probe-cli/internal/utils"
+	"github.com/ooni/probe-engine/version"
+	"github.com/pkg/errors"
+)
+
+// manifestName is the name of the manifest inside the result directory.
+const manifestName = "manifest.json"
+
+// Manifest describes a run with enough detail to reproduce it. We write
+// the manifest alongside the measurements at the end of each run.
+type Manifest struct {
+	SoftwareName    string            `json:"software_name"`
+	SoftwareVersion string            `json:"software_version"`
+	EngineVersion   string            `json:"engine_version"`
+	TestGroupName   string            `json:"test_group_name"`
+	StartTime       time.Time         `json:"start_time"`
+	EndTime         time.Time         `json:"end_time"`
+	Network         ManifestNetwork   `json:"network"`
+	Nettests        []ManifestNettest `json:"nettests"`
+
+	// Assets maps the name of each asset to its SHA256.
+	Assets map[string]string `json:"assets"`
+
+	// Leaks contains the resources leaked by nettests, if we have
+	// been asked to detect leaks.
+	Leaks []ManifestLeak `json:"leaks,omitempty"`
+
+	mu sync.Mutex
+}
+
+// ManifestNetwork is the network fingerprint inside a Manifest.
+type ManifestNetwork struct {
+	ASN         string `json:"probe_asn"`
+	CountryCode string `json:"probe_cc"`
+	NetworkName string `json:"probe_network_name"`
+	ResolverIP  string `json:"resolver_ip"`
+}
+
+// ManifestNettest describes a nettest inside a Manifest.
+type ManifestNettest struct {
+	TestName     string            `json:"test_name"`
+	TestVersion  string            `json:"test_version"`
+	Annotations  map[string]string `json:"annotations"`
+	InputsCount  int               `json:"inputs_count"`
+	InputsSHA256 string            `json:"inputs_sha256"`
+}
+
+// ManifestLeak describes the resources leaked by a nettest.
+type ManifestLeak struct {
+	Nettest    string `json:"nettest"`
+	Goroutines int    `json:"goroutines"`
+	FDs        int    `json:"fds"`
+}
+
+func newManifest(probe *ooni.Probe, groupName string, loc enginex.LocationProvider) *Manifest {
+	return &Manifest{
+		SoftwareName:    probe.SoftwareName(),
+		SoftwareVersion: probe.SoftwareVersion(),
+		EngineVersion:   version.Version,
+		TestGroupName:   groupName,
+		StartTime:       time.Now().UTC(),
+		Network: ManifestNetwork{
+			ASN:         loc.ProbeASNString(),
+			CountryCode: loc.ProbeCC(),
+			NetworkName: loc.ProbeNetworkName(),
+			ResolverIP:  loc.ResolverIP(),
+		},
+		Assets: make(map[string]string),
+	}
+}
+
+// addNettest records that we have run a nettest with the given inputs.
+func (m *Manifest) addNettest(testName, testVersion string, annotations map[string]string, inputs []string) {
+	sum := sha256.Sum256([]byte(strings.Join(inputs, "\n")))
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.Nettests = append(m.Nettests, ManifestNettest{
+		TestName:     testName,
+		TestVersion:  testVersion,
+		Annotations:  annotations,
+		InputsCount:  len(inputs),
+		InputsSHA256: hex.EncodeToString(sum[:]),
+	})
+}
+
+// addLeak records that a nettest has leaked resources.
+func (m *Manifest) addLeak(leak ManifestLeak) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.Leaks = append(m.Leaks, leak)
+}
+
+// write hashes the assets and writes the manifest into dir.
+func (m *Manifest) write(home, dir string) error {
+	m.EndTime = time.Now().UTC()
+	assetsDir := utils.AssetsDir(home)
+	infos, err := ioutil.ReadDir(assetsDir)
+	if err != nil {
+		return errors.Wrap(err, "listing assets")
+	}
+	for _, info := range infos {
+		if !info.Mode().IsRegular() {
+			continue
+		}
+		sum, err := sha256File(filepath.Join(assetsDir, info.Name()))
+		if err != nil {
+			return err
+		}
+		m.Assets[info.Name()] = sum
+	}
+	data, err := json.MarshalIndent(m, "", "  ")
+	if err != nil {
+		return err
+	}
+	return ioutil.WriteFile(filepath.Join(dir, manifestName), data, 0600)
+}
+
+func sha256File(filename string) (string, error) {
+	fp, err := os.Open(filename)
+	if err != nil {
+		return "", err
+	}
+	defer fp.Close()
+	hasher := sha256.New()
+	if _, err := io.Copy(hasher, fp); err != nil {
+		return "", err
+	}
+	return hex.EncodeToString(hasher.Sum(nil)), nil
+}
@@ -0,0 +1,48 @@
+package nettests
+
+import (
+	"encoding/json"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/ooni/probe-cli/internal/utils"
+)
+
+func TestManifestWrite(t *testing.T) {
+	home, err := ioutil.TempDir("", "ooniprobetests")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(home)
+	if err := os.MkdirAll(utils.AssetsDir(home), 0700); err != nil {
+		t.Fatal(err)
+	}
+	asset := filepath.Join(utils.AssetsDir(home), "asn.mmdb")
+	if err := ioutil.WriteFile(asset, []byte("antani"), 0600); err != nil {
+		t.Fatal(err)
+	}
+	m := &Manifest{Assets: make(map[string]string)}
+	m.addNettest("web_connectivity", "0.2.0", nil, []string{"https://example.com/"})
+	if err := m.write(home, home); err != nil {
+		t.Fatal(err)
+	}
+	data, err := ioutil.ReadFile(filepath.Join(home, manifestName))
+	if err != nil {
+		t.Fatal(err)
+	}
+	var got Manifest
+	if err := json.Unmarshal(data, &got); err != nil {
+		t.Fatal(err)
+	}
+	if len(got.Nettests) != 1 || got.Nettests[0].InputsCount != 1 {
+		t.Fatal("unexpected nettests")
+	}
+	if got.Assets["asn.mmdb"] != "b1dc5f0ba862fe3a1608d985ded3c5ed6b9a7418db186d9e6e6201794f59ba54" {
+		t.Fatal("unexpected asset hash")
+	}
+	if got.EndTime.IsZero() {
+		t.Fatal("end time not set")
+	}
+}
@@ -3,11 +3,15 @@ package nettests
 import (
 	"database/sql"
 	"fmt"
+	"sync"
 	"time"
 
 	"github.com/apex/log"
 	"github.com/fatih/color"
+	"github.com/ooni/probe-cli/internal/config"
 	"github.com/ooni/probe-cli/internal/database"
+	"github.com/ooni/probe-cli/internal/descriptor"
+	"github.com/ooni/probe-cli/internal/msmttrim"
 	"github.com/ooni/probe-cli/internal/ooni"
 	"github.com/ooni/probe-cli/internal/output"
 	engine "github.com/ooni/probe-engine"
@@ -28,6 +32,7 @@ func NewController(
 		nt:      nt,
 		res:     res,
 		Session: sess,
+		mu:      &sync.Mutex{},
 	}
 }
 
@@ -58,6 +63,52 @@ type Controller struct {
 
 	// curInputIdx is the current input index
 	curInputIdx int
+
+	// annotations contains annotations to add to every measurement
+	annotations map[string]string
+
+	// inputAnnotations maps an input index to its annotations
+	inputAnnotations map[int]map[string]string
+
+	// expectedOutcomes maps an input index to its expected outcome
+	expectedOutcomes map[int]string
+
+	// manifest is the optional manifest of the run
+	manifest *Manifest
+
+	// mu serializes the accesses to the database and the updates of res,
+	// which are shared by the nettests of a group running concurrently
+	mu *sync.Mutex
+}
+
+// locked runs fn while holding the lock that serializes the accesses to
+// the database and the updates of the result. Sqlite does not cope well
+// with concurrent writers, hence every database access must use it.
+func (c *Controller) locked(fn func() error) error {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return fn()
+}
+
+// SetInputAnnotations sets the per-input annotations. The key of the
+// map is the index of the input inside the list of inputs.
+func (c *Controller) SetInputAnnotations(annotations map[int]map[string]string) {
+	c.inputAnnotations = annotations
+}
+
+// SetExpectedOutcomes sets the per-input expected outcomes. The key of
+// the map is the index of the input inside the list of inputs.
+func (c *Controller) SetExpectedOutcomes(outcomes map[int]string) {
+	c.expectedOutcomes = outcomes
+}
+
+// AddAnnotation adds an annotation that will be included into
+// every measurement performed using this controller.
+func (c *Controller) AddAnnotation(key, value string) {
+	if c.annotations == nil {
+		c.annotations = make(map[string]string)
+	}
+	c.annotations[key] = value
 }
 
 // SetInputIdxMap is used to set the mapping of index into input. This mapping
@@ -86,13 +137,21 @@ func (c *Controller) Run(builder *engine.ExperimentBuilder, inputs []string) err
 	builder.SetCallbacks(model.ExperimentCallbacks(c))
 	c.numInputs = len(inputs)
 	exp := builder.NewExperiment()
-	defer func() {
+	defer c.locked(func() error {
 		c.res.DataUsageDown += exp.KibiBytesReceived()
 		c.res.DataUsageUp += exp.KibiBytesSent()
-	}()
+		return nil
+	})
 
 	c.msmts = make(map[int64]*database.Measurement)
 
+	var testVersion string
+	defer func() {
+		if c.manifest != nil {
+			c.manifest.addNettest(exp.Name(), testVersion, c.annotations, inputs)
+		}
+	}()
+
 	// These values are shared by every measurement
 	var reportID sql.NullString
 	resultID := c.res.ID
@@ -126,9 +185,22 @@ func (c *Controller) Run(builder *engine.ExperimentBuilder, inputs []string) err
 			urlID = sql.NullInt64{Int64: c.inputIdxMap[idx64], Valid: true}
 		}
 
-		msmt, err := database.CreateMeasurement(
-			c.Probe.DB(), reportID, exp.Name(), c.res.MeasurementDir, idx, resultID, urlID,
-		)
+		isDuplicate, err := c.isDuplicate(exp.Name(), urlID)
+		if err != nil {
+			return errors.Wrap(err, "failed to check for duplicate measurements")
+		}
+		if isDuplicate && c.Probe.Config().Nettests.DuplicatePolicy == config.DuplicatePolicySkip {
+			log.Infof("Skipping %s %s: measured recently on this network", exp.Name(), input)
+			continue
+		}
+
+		var msmt *database.Measurement
+		err = c.locked(func() (err error) {
+			msmt, err = database.CreateMeasurement(
+				c.Probe.DB(), reportID, exp.Name(), c.res.MeasurementDir, idx, resultID, urlID,
+			)
+			return
+		})
 		if err != nil {
 			return errors.Wrap(err, "failed to create measurement")
 		}
@@ -140,7 +212,11 @@ func (c *Controller) Run(builder *engine.ExperimentBuilder, inputs []string) err
 		measurement, err := exp.Measure(input)
 		if err != nil {
 			log.WithError(err).Debug(color.RedString("failure.measurement"))
-			if err := c.msmts[idx64].Failed(c.Probe.DB(), err.Error()); err != nil {
+			failure := err.Error()
+			err := c.locked(func() error {
+				return c.msmts[idx64].Failed(c.Probe.DB(), failure)
+			})
+			if err != nil {
 				return errors.Wrap(err, "failed to mark measurement as failed")
 			}
 			// Even with a failed measurement, we want to continue. We want to
@@ -148,25 +224,57 @@ func (c *Controller) Run(builder *engine.ExperimentBuilder, inputs []string) err
 			// is useful for local inspection. Submitting it is useful to us to
 			// undertsand what went wrong (censorship? bug? anomaly?).
 		}
+		testVersion = measurement.TestVersion
+		measurement.AddAnnotations(c.annotations)
+		measurement.AddAnnotations(c.inputAnnotations[idx])
+		if expected, found := c.expectedOutcomes[idx]; found {
+			measurement.AddAnnotation(expectedOutcomeAnnotation, expected)
+		}
+		if isDuplicate {
+			measurement.AddAnnotation(duplicateAnnotation, "true")
+		}
 
 		if c.Probe.Config().Sharing.UploadResults {
 			// Implementation note: SubmitMeasurement will fail here if we did fail
 			// to open the report but we still want to continue. There will be a
 			// bit of a spew in the logs, perhaps, but stopping seems less efficient.
-			if err := exp.SubmitAndUpdateMeasurement(measurement); err != nil {
+			//
+			// We submit a trimmed copy of too large measurements, so that the
+			// collector accepts them, but we save the complete measurement.
+			submitted, trimmed, err := msmttrim.Trim(measurement, msmttrim.MaxSize)
+			if err != nil {
+				log.WithError(err).Warn("failed to trim the measurement")
+				submitted = measurement
+			} else if len(trimmed) > 0 {
+				log.Infof("Trimmed %d fields to submit the measurement", len(trimmed))
+			}
+			if err := exp.SubmitAndUpdateMeasurement(submitted); err != nil {
 				log.Debug(color.RedString("failure.measurement_submission"))
-				if err := c.msmts[idx64].UploadFailed(c.Probe.DB(), err.Error()); err != nil {
+				failure := err.Error()
+				err := c.locked(func() error {
+					return c.msmts[idx64].UploadFailed(c.Probe.DB(), failure)
+				})
+				if err != nil {
 					return errors.Wrap(err, "failed to mark upload as failed")
 				}
-			} else if err := c.msmts[idx64].UploadSucceeded(c.Probe.DB()); err != nil {
-				return errors.Wrap(err, "failed to mark upload as succeeded")
+			} else {
+				err := c.locked(func() error {
+					return c.msmts[idx64].UploadSucceeded(c.Probe.DB())
+				})
+				if err != nil {
+					return errors.Wrap(err, "failed to mark upload as succeeded")
+				}
 			}
+			measurement.ReportID = submitted.ReportID
 		}
 
 		if err := exp.SaveMeasurement(measurement, msmt.MeasurementFilePath.String); err != nil {
 			return errors.Wrap(err, "failed to save measurement on disk")
 		}
-		if err := c.msmts[idx64].Done(c.Probe.DB()); err != nil {
+		err = c.locked(func() error {
+			return c.msmts[idx64].Done(c.Probe.DB())
+		})
+		if err != nil {
 			return errors.Wrap(err, "failed to mark measurement as done")
 		}
 
@@ -181,15 +289,68 @@ func (c *Controller) Run(builder *engine.ExperimentBuilder, inputs []string) err
 			continue
 		}
 		log.Debugf("Fetching: %d %v", idx, c.msmts[idx64])
-		if err := database.AddTestKeys(c.Probe.DB(), c.msmts[idx64], tk); err != nil {
+		err = c.locked(func() error {
+			return database.AddTestKeys(c.Probe.DB(), c.msmts[idx64], tk)
+		})
+		if err != nil {
 			return errors.Wrap(err, "failed to add test keys to summary")
 		}
+		c.checkExpectedOutcome(idx, input, c.msmts[idx64])
 	}
 
 	log.Debugf("status.end")
 	return nil
 }
 
+// duplicateAnnotation is the annotation we add to duplicate measurements.
+const duplicateAnnotation = "is_duplicate"
+
+// isDuplicate returns whether we have already measured the given
+// input with the given experiment on this network recently.
+func (c *Controller) isDuplicate(testName string, urlID sql.NullInt64) (bool, error) {
+	window := c.Probe.Config().Nettests.DuplicateWindowSeconds
+	if window <= 0 {
+		return false, nil
+	}
+	since := time.Now().Add(-time.Duration(window) * time.Second)
+	var found bool
+	err := c.locked(func() (err error) {
+		found, err = database.HasRecentMeasurement(
+			c.Probe.DB(), testName, urlID, c.Session.ProbeASN(), since, c.res.ID,
+		)
+		return
+	})
+	return found, err
+}
+
+// expectedOutcomeAnnotation is the annotation containing the expected
+// outcome of a measurement, when it is known.
+const expectedOutcomeAnnotation = "expected_outcome"
+
+// checkExpectedOutcome compares the outcome of the measurement of the
+// input having the given index with the expected outcome, if any.
+func (c *Controller) checkExpectedOutcome(idx int, input string, msmt *database.Measurement) {
+	expected, found := c.expectedOutcomes[idx]
+	if !found {
+		return
+	}
+	if !msmt.IsAnomaly.Valid {
+		log.Warnf("Cannot compare %s with the expected outcome", input)
+		return
+	}
+	actual := descriptor.OutcomeOK
+	if msmt.IsAnomaly.Bool {
+		actual = descriptor.OutcomeAnomaly
+	}
+	log.WithFields(log.Fields{
+		"type":     "expected_outcome",
+		"input":    input,
+		"expected": expected,
+		"actual":   actual,
+		"matches":  expected == actual,
+	}).Info("compared with the expected outcome")
+}
+
 // OnProgress should be called when a new progress event is available.
 func (c *Controller) OnProgress(perc float64, msg string) {
 	log.Debugf("OnProgress: %f - %s", perc, msg)
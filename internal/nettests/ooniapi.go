@@ -0,0 +1,124 @@
+package nettests
+
+import (
+	"context"
+	"encoding/json"
+	"io"
+	"io/ioutil"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+
+	"github.com/pkg/errors"
+)
+
+// ooniAPIBaseURL is the base URL of the OONI API.
+const ooniAPIBaseURL = "https://api.ooni.io"
+
+// maxAPIResponseSize is the maximum size of an OONI API response.
+const maxAPIResponseSize = 1 << 24
+
+// publishedMeasurement contains the fields of a measurement published by
+// the OONI API that we need to store it into the results database.
+type publishedMeasurement struct {
+	Input       interface{} `json:"input"`
+	ASN         string      `json:"probe_asn"`
+	CC          string      `json:"probe_cc"`
+	IP          string      `json:"probe_ip"`
+	NetworkName string      `json:"probe_network_name"`
+	Resolver    string      `json:"resolver_ip"`
+	ReportID    string      `json:"report_id"`
+	TestName    string      `json:"test_name"`
+}
+
+// InputString returns the input of the measurement or an empty
+// string for measurements without input.
+func (m *publishedMeasurement) InputString() string {
+	s, _ := m.Input.(string)
+	return s
+}
+
+// ProbeASN implements enginex.LocationProvider.ProbeASN
+func (m *publishedMeasurement) ProbeASN() uint {
+	asn, _ := strconv.ParseUint(strings.TrimPrefix(m.ASN, "AS"), 10, 32)
+	return uint(asn)
+}
+
+// ProbeASNString implements enginex.LocationProvider.ProbeASNString
+func (m *publishedMeasurement) ProbeASNString() string {
+	return m.ASN
+}
+
+// ProbeCC implements enginex.LocationProvider.ProbeCC
+func (m *publishedMeasurement) ProbeCC() string {
+	return m.CC
+}
+
+// ProbeIP implements enginex.LocationProvider.ProbeIP
+func (m *publishedMeasurement) ProbeIP() string {
+	return m.IP
+}
+
+// ProbeNetworkName implements enginex.LocationProvider.ProbeNetworkName
+func (m *publishedMeasurement) ProbeNetworkName() string {
+	return m.NetworkName
+}
+
+// ResolverIP implements enginex.LocationProvider.ResolverIP
+func (m *publishedMeasurement) ResolverIP() string {
+	return m.Resolver
+}
+
+// apiGet fetches the given URL of the OONI API.
+func apiGet(ctx context.Context, client *http.Client, URL string) ([]byte, error) {
+	req, err := http.NewRequest("GET", URL, nil)
+	if err != nil {
+		return nil, err
+	}
+	resp, err := client.Do(req.WithContext(ctx))
+	if err != nil {
+		return nil, errors.Wrap(err, "querying the OONI API")
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != 200 {
+		return nil, errors.Errorf("querying the OONI API: %s", resp.Status)
+	}
+	data, err := ioutil.ReadAll(io.LimitReader(resp.Body, maxAPIResponseSize))
+	if err != nil {
+		return nil, errors.Wrap(err, "reading the OONI API response")
+	}
+	return data, nil
+}
+
+// fetchReport returns the raw measurements of the given report
+// published by the OONI API at baseURL.
+func fetchReport(ctx context.Context, client *http.Client, baseURL, reportID string) ([][]byte, error) {
+	query := url.Values{}
+	query.Set("report_id", reportID)
+	query.Set("limit", "10000")
+	data, err := apiGet(ctx, client, baseURL+"/api/v1/measurements?"+query.Encode())
+	if err != nil {
+		return nil, err
+	}
+	var list struct {
+		Results []struct {
+			MeasurementURL string `json:"measurement_url"`
+		} `json:"results"`
+	}
+	if err := json.Unmarshal(data, &list); err != nil {
+		return nil, errors.Wrap(err, "parsing the measurements list")
+	}
+	var out [][]byte
+	for _, entry := range list.Results {
+		if entry.MeasurementURL == "" {
+			continue
+		}
+		data, err := apiGet(ctx, client, entry.MeasurementURL)
+		if err != nil {
+			return nil, err
+		}
+		out = append(out, data)
+	}
+	return out, nil
+}
@@ -0,0 +1,54 @@
+package nettests
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+func TestFetchReport(t *testing.T) {
+	var server *httptest.Server
+	server = httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/api/v1/measurements":
+			if r.URL.Query().Get("report_id") != "20201201T000000Z_AS30722_antani" {
+				w.WriteHeader(400)
+				return
+			}
+			fmt.Fprintf(w, `{"results":[{"measurement_url":"%s/m/1"},{"measurement_url":""}]}`, server.URL)
+		case "/m/1":
+			fmt.Fprint(w, `{"input":"https://www.example.com/","probe_asn":"AS30722","test_name":"web_connectivity"}`)
+		default:
+			w.WriteHeader(404)
+		}
+	}))
+	defer server.Close()
+	out, err := fetchReport(context.Background(), http.DefaultClient,
+		server.URL, "20201201T000000Z_AS30722_antani")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(out) != 1 {
+		t.Fatalf("unexpected number of measurements: %d", len(out))
+	}
+	if _, err := fetchReport(context.Background(), http.DefaultClient,
+		server.URL, "antani"); err == nil {
+		t.Fatal("expected an error here")
+	}
+}
+
+func TestPublishedMeasurement(t *testing.T) {
+	m := publishedMeasurement{Input: "https://www.example.com/", ASN: "AS30722"}
+	if m.ProbeASN() != 30722 {
+		t.Fatalf("unexpected ASN: %d", m.ProbeASN())
+	}
+	if m.InputString() != "https://www.example.com/" {
+		t.Fatal("unexpected input")
+	}
+	m = publishedMeasurement{Input: []interface{}{"a", "b"}}
+	if m.ProbeASN() != 0 || m.InputString() != "" {
+		t.Fatal("unexpected values for missing fields")
+	}
+}
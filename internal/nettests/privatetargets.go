@@ -0,0 +1,88 @@
+package nettests
+
+import (
+	"net"
+	"net/url"
+	"strings"
+
+	"github.com/apex/log"
+	"github.com/ooni/probe-engine/model"
+)
+
+// privateTargetAnnotation is the annotation containing the number of
+// inputs skipped because they pointed into private address space.
+const privateTargetAnnotation = "private_targets_skipped"
+
+// privateNetworks contains the address space we refuse to measure.
+var privateNetworks = mustParseCIDRs(
+	"0.0.0.0/8",      // "this" network
+	"10.0.0.0/8",     // RFC1918
+	"100.64.0.0/10",  // RFC6598 (CGNAT)
+	"127.0.0.0/8",    // loopback
+	"169.254.0.0/16", // link-local
+	"172.16.0.0/12",  // RFC1918
+	"192.168.0.0/16", // RFC1918
+	"::/128",         // unspecified
+	"::1/128",        // loopback
+	"fc00::/7",       // unique local
+	"fe80::/10",      // link-local
+)
+
+func mustParseCIDRs(cidrs ...string) []*net.IPNet {
+	var out []*net.IPNet
+	for _, cidr := range cidrs {
+		_, ipnet, err := net.ParseCIDR(cidr)
+		if err != nil {
+			panic(err)
+		}
+		out = append(out, ipnet)
+	}
+	return out
+}
+
+// isPrivateHost returns whether the given host (without port) is a local
+// name or an IP address in private, loopback or link-local address space.
+func isPrivateHost(host string) bool {
+	host = strings.ToLower(strings.TrimSuffix(host, "."))
+	if host == "localhost" || strings.HasSuffix(host, ".localhost") ||
+		strings.HasSuffix(host, ".local") {
+		return true
+	}
+	ip := net.ParseIP(host)
+	if ip == nil {
+		return false
+	}
+	for _, ipnet := range privateNetworks {
+		if ipnet.Contains(ip) {
+			return true
+		}
+	}
+	return false
+}
+
+// isPrivateTarget returns whether the given input, which is either a URL
+// or an endpoint, points into private address space. We do not resolve
+// domain names, so this only catches local names and IP addresses.
+func isPrivateTarget(input string) bool {
+	if parsed, err := url.Parse(input); err == nil && parsed.Host != "" {
+		return isPrivateHost(parsed.Hostname())
+	}
+	if host, _, err := net.SplitHostPort(input); err == nil {
+		return isPrivateHost(host)
+	}
+	return isPrivateHost(input)
+}
+
+// filterPrivateTargets returns the URLs that do not point into private
+// address space along with the number of URLs that we have skipped.
+func filterPrivateTargets(in []model.URLInfo) ([]model.URLInfo, int) {
+	var out []model.URLInfo
+	for _, info := range in {
+		if isPrivateTarget(info.URL) {
+			log.Warnf("Skipping %s because it points into private address space", info.URL)
+			continue
+		}
+		out = append(out, info)
+	}
+	return out, len(in) - len(out)
+}
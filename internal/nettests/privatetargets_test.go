@@ -0,0 +1,35 @@
+package nettests
+
+import "testing"
+
+func TestIsPrivateTarget(t *testing.T) {
+	private := []string{
+		"http://192.168.1.1/",
+		"https://10.0.0.1:8443/admin",
+		"http://127.0.0.1/",
+		"http://[::1]/",
+		"http://[fe80::1]/",
+		"http://169.254.169.254/latest/meta-data/",
+		"http://localhost:8080/",
+		"http://printer.local/",
+		"172.16.0.1:443",
+		"100.64.1.1",
+	}
+	for _, input := range private {
+		if !isPrivateTarget(input) {
+			t.Fatalf("expected %s to be private", input)
+		}
+	}
+	public := []string{
+		"https://www.example.com/",
+		"http://8.8.8.8/",
+		"http://[2001:4860:4860::8888]/",
+		"8.8.4.4:53",
+		"172.32.0.1",
+	}
+	for _, input := range public {
+		if isPrivateTarget(input) {
+			t.Fatalf("expected %s to be public", input)
+		}
+	}
+}
@@ -0,0 +1,105 @@
+package nettests
+
+import (
+	"encoding/json"
+	"io/ioutil"
+
+	"github.com/apex/log"
+	"github.com/ooni/probe-cli/internal/database"
+	"github.com/ooni/probe-cli/internal/ooni"
+	engine "github.com/ooni/probe-engine"
+	"github.com/ooni/probe-engine/model"
+	"github.com/pkg/errors"
+)
+
+// reprocessor recomputes the summary of stored measurements.
+type reprocessor struct {
+	probe       *ooni.Probe
+	sess        *engine.Session
+	experiments map[string]*engine.Experiment
+}
+
+// experiment returns the experiment with the given name, which we
+// only use to compute summaries and hence never run.
+func (r *reprocessor) experiment(testName string) (*engine.Experiment, error) {
+	if exp, found := r.experiments[testName]; found {
+		return exp, nil
+	}
+	builder, err := r.sess.NewExperimentBuilder(testName)
+	if err != nil {
+		return nil, err
+	}
+	exp := builder.NewExperiment()
+	r.experiments[testName] = exp
+	return exp, nil
+}
+
+// reprocess recomputes the summary of a single measurement.
+func (r *reprocessor) reprocess(msmt *database.Measurement) error {
+	data, err := ioutil.ReadFile(msmt.MeasurementFilePath.String)
+	if err != nil {
+		return errors.Wrap(err, "reading measurement")
+	}
+	var measurement model.Measurement
+	if err := json.Unmarshal(data, &measurement); err != nil {
+		return errors.Wrap(err, "parsing measurement")
+	}
+	exp, err := r.experiment(msmt.TestName)
+	if err != nil {
+		return err
+	}
+	tk, err := exp.GetSummaryKeys(&measurement)
+	if err != nil {
+		return errors.Wrap(err, "computing summary")
+	}
+	return database.AddTestKeys(r.probe.DB(), msmt, tk)
+}
+
+// Reprocess recomputes the summary, and hence the anomaly verdict, of the
+// stored measurements of the given result using the current engine, such
+// that users benefit from improved heuristics without measuring again. When
+// resultID is zero, we reprocess all the results. Returns the number of
+// measurements we have reprocessed.
+func Reprocess(probe *ooni.Probe, resultID int64) (int, error) {
+	var resultIDs []int64
+	if resultID > 0 {
+		resultIDs = append(resultIDs, resultID)
+	} else {
+		doneResults, incompleteResults, err := database.ListResults(probe.DB())
+		if err != nil {
+			return 0, err
+		}
+		for _, result := range append(doneResults, incompleteResults...) {
+			resultIDs = append(resultIDs, result.Result.ID)
+		}
+	}
+	sess, err := probe.NewSession()
+	if err != nil {
+		return 0, err
+	}
+	defer sess.Close()
+	r := &reprocessor{
+		probe:       probe,
+		sess:        sess,
+		experiments: make(map[string]*engine.Experiment),
+	}
+	var count int
+	for _, id := range resultIDs {
+		measurements, err := database.ListMeasurements(probe.DB(), id)
+		if err != nil {
+			return count, err
+		}
+		for _, msmt := range measurements {
+			if !msmt.Measurement.IsDone || !msmt.MeasurementFilePath.Valid {
+				continue
+			}
+			if err := r.reprocess(&msmt.Measurement); err != nil {
+				log.WithError(err).Warnf(
+					"cannot reprocess measurement #%d", msmt.Measurement.ID)
+				continue
+			}
+			count++
+		}
+	}
+	return count, nil
+}
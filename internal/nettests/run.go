@@ -1,8 +1,15 @@
 package nettests
 
 import (
+	"fmt"
+	"strconv"
+	"sync"
+	"time"
+
 	"github.com/apex/log"
 	"github.com/ooni/probe-cli/internal/database"
+	"github.com/ooni/probe-cli/internal/descriptor"
+	"github.com/ooni/probe-cli/internal/errcode"
 	"github.com/ooni/probe-cli/internal/ooni"
 	"github.com/pkg/errors"
 )
@@ -13,6 +20,63 @@ type RunGroupConfig struct {
 	Probe      *ooni.Probe
 	InputFiles []string
 	Inputs     []string
+
+	// Descriptor is the optional run descriptor. When it is set, we
+	// run the nettests it describes and ignore GroupName.
+	Descriptor *descriptor.Descriptor
+}
+
+// uploadBacklogAnnotation is the annotation containing the number of
+// measurements we previously failed to submit, which allows to detect
+// problems reaching the collector.
+const uploadBacklogAnnotation = "upload_backlog"
+
+// profileAnnotation is the annotation containing the active profile.
+const profileAnnotation = "profile"
+
+// isBandwidthIntensive returns whether the nettest may saturate the
+// link, in which case it must not run concurrently with other nettests.
+func isBandwidthIntensive(nt Nettest) bool {
+	switch v := nt.(type) {
+	case Dash, NDT:
+		return true
+	case DescriptorNettest:
+		return v.Nettest.TestName == "dash" || v.Nettest.TestName == "ndt"
+	}
+	return false
+}
+
+// runNettests calls run for each nettest in a background goroutine. We
+// run at most parallelism nettests concurrently and bandwidth intensive
+// nettests always run alone. We tell run whether the nettest runs alone,
+// in which case it may use the state shared by the whole group.
+func runNettests(probe *ooni.Probe, nettests []Nettest, parallelism int, run func(i int, nt Nettest, exclusive bool)) {
+	if parallelism < 1 {
+		parallelism = 1
+	}
+	var wg sync.WaitGroup
+	sema := make(chan bool, parallelism)
+	for i, nt := range nettests {
+		if probe.IsTerminated() == true {
+			log.Debugf("context is terminated, stopping group.Nettests early")
+			break
+		}
+		exclusive := parallelism <= 1 || isBandwidthIntensive(nt)
+		if exclusive {
+			wg.Wait()
+		}
+		sema <- true
+		wg.Add(1)
+		go func(i int, nt Nettest) {
+			defer wg.Done()
+			defer func() { <-sema }()
+			run(i, nt, exclusive)
+		}(i, nt)
+		if exclusive {
+			wg.Wait()
+		}
+	}
+	wg.Wait()
 }
 
 // RunGroup runs a group of nettests according to the specified config.
@@ -32,7 +96,7 @@ func RunGroup(config RunGroupConfig) error {
 	err = sess.MaybeLookupLocation()
 	if err != nil {
 		log.WithError(err).Error("Failed to lookup the location of the probe")
-		return err
+		return errcode.New(errcode.GeolocationFailed, err)
 	}
 	network, err := database.CreateNetwork(config.Probe.DB(), sess)
 	if err != nil {
@@ -41,10 +105,14 @@ func RunGroup(config RunGroupConfig) error {
 	}
 	if err := sess.MaybeLookupBackends(); err != nil {
 		log.WithError(err).Warn("Failed to discover OONI backends")
-		return err
+		return errcode.New(errcode.BackendUnreachable, err)
 	}
 
-	group, ok := All[config.GroupName]
+	groupName := config.GroupName
+	group, ok := All[groupName]
+	if config.Descriptor != nil {
+		groupName, group, ok = DescriptorGroupName, descriptorGroup(config.Descriptor), true
+	}
 	if !ok {
 		log.Errorf("No test group named %s", config.GroupName)
 		return errors.New("invalid test group name")
@@ -52,29 +120,74 @@ func RunGroup(config RunGroupConfig) error {
 	log.Debugf("Running test group %s", group.Label)
 
 	result, err := database.CreateResult(
-		config.Probe.DB(), config.Probe.Home(), config.GroupName, network.ID)
+		config.Probe.DB(), config.Probe.Home(), groupName, network.ID)
 	if err != nil {
 		log.Errorf("DB result error: %s", err)
 		return err
 	}
+	manifest := newManifest(config.Probe, groupName, sess)
+	backlog, err := database.GetUploadBacklog(config.Probe.DB())
+	if err != nil {
+		log.WithError(err).Warn("Failed to get the upload backlog")
+	}
+	versions, err := versionAnnotations(config.Probe.Home())
+	if err != nil {
+		log.WithError(err).Warn("Failed to read the assets versions")
+	}
+
+	// All the nettests share sess, including the ones running concurrently.
+	// We have already looked up the location and the backends, which are
+	// the only lookups mutating the session, so nettests only read it and
+	// we do not repeat the geolocation for each concurrent nettest.
+	parallelism := config.Probe.Config().Nettests.MaxParallelNettests
+	var mu sync.Mutex
 
 	config.Probe.ListenForSignals()
 	config.Probe.MaybeListenForStdinClosed()
-	for i, nt := range group.Nettests {
-		if config.Probe.IsTerminated() == true {
-			log.Debugf("context is terminated, stopping group.Nettests early")
-			break
-		}
+	runNettests(config.Probe, group.Nettests, parallelism, func(i int, nt Nettest, exclusive bool) {
 		log.Debugf("Running test %T", nt)
 		ctl := NewController(nt, config.Probe, result, sess)
 		ctl.InputFiles = config.InputFiles
 		ctl.Inputs = config.Inputs
 		ctl.SetNettestIndex(i, len(group.Nettests))
-		if err = nt.Run(ctl); err != nil {
+		ctl.manifest = manifest
+		ctl.mu = &mu
+		ctl.AddAnnotation(uploadBacklogAnnotation, strconv.FormatUint(backlog.Count, 10))
+		for key, value := range versions {
+			ctl.AddAnnotation(key, value)
+		}
+		if name, profile, found := config.Probe.Config().ActiveProfile(); found {
+			ctl.AddAnnotation(profileAnnotation, name)
+			for key, value := range profile.Annotations {
+				ctl.AddAnnotation(key, value)
+			}
+		}
+		ctl.AddAnnotation(timeOfDayAnnotation, TimeOfDay(time.Now()))
+		detectLeaksEnabled := exclusive && config.Probe.Config().Advanced.DetectLeaks
+		var before resourceSnapshot
+		if detectLeaksEnabled {
+			before = takeResourceSnapshot()
+		}
+		if err := nt.Run(ctl); err != nil {
 			log.WithError(err).Errorf("Failed to run %s", group.Label)
 		}
-	}
+		if !detectLeaksEnabled {
+			return
+		}
+		if leaked := detectLeaks(before); leaked.hasLeaks() {
+			log.Warnf("%T leaked %d goroutines and %d file descriptors",
+				nt, leaked.Goroutines, leaked.FDs)
+			manifest.addLeak(ManifestLeak{
+				Nettest:    fmt.Sprintf("%T", nt),
+				Goroutines: leaked.Goroutines,
+				FDs:        leaked.FDs,
+			})
+		}
+	})
 
+	if err := manifest.write(config.Probe.Home(), result.MeasurementDir); err != nil {
+		log.WithError(err).Warn("Failed to write the run manifest")
+	}
 	if err = result.Finished(config.Probe.DB()); err != nil {
 		return err
 	}
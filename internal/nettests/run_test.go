@@ -0,0 +1,94 @@
+package nettests
+
+import (
+	"database/sql"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/ooni/probe-cli/internal/database"
+)
+
+// inertNettest does not measure anything. It waits until all the inert
+// nettests are running, such that we know they run concurrently, and then
+// it writes into the database and updates the result.
+type inertNettest struct {
+	arrived chan<- bool
+	ready   <-chan bool
+}
+
+func (n inertNettest) Run(ctl *Controller) error {
+	n.arrived <- true
+	<-n.ready
+	var msmt *database.Measurement
+	err := ctl.locked(func() (err error) {
+		msmt, err = database.CreateMeasurement(
+			ctl.Probe.DB(), sql.NullString{}, "inert", ctl.res.MeasurementDir,
+			0, ctl.res.ID, sql.NullInt64{},
+		)
+		return
+	})
+	if err != nil {
+		return err
+	}
+	return ctl.locked(func() error {
+		ctl.res.DataUsageUp++
+		return msmt.Done(ctl.Probe.DB())
+	})
+}
+
+func TestRunNettestsConcurrently(t *testing.T) {
+	probe := newOONIProbe(t)
+	location := &publishedMeasurement{ASN: "AS30722", CC: "IT"}
+	network, err := database.CreateNetwork(probe.DB(), location)
+	if err != nil {
+		t.Fatal(err)
+	}
+	res, err := database.CreateResult(probe.DB(), probe.Home(), "inert", network.ID)
+	if err != nil {
+		t.Fatal(err)
+	}
+	arrived, ready := make(chan bool), make(chan bool)
+	nettests := []Nettest{
+		inertNettest{arrived: arrived, ready: ready},
+		inertNettest{arrived: arrived, ready: ready},
+	}
+	var (
+		mu   sync.Mutex
+		errs = make(chan error, len(nettests))
+		done = make(chan bool)
+	)
+	go func() {
+		runNettests(probe, nettests, len(nettests), func(i int, nt Nettest, exclusive bool) {
+			ctl := NewController(nt, probe, res, nil)
+			ctl.mu = &mu
+			errs <- nt.Run(ctl)
+		})
+		close(done)
+	}()
+	for range nettests {
+		select {
+		case <-arrived:
+		case <-time.After(10 * time.Second):
+			close(ready)
+			t.Fatal("the nettests did not run concurrently")
+		}
+	}
+	close(ready)
+	<-done
+	for range nettests {
+		if err := <-errs; err != nil {
+			t.Fatal(err)
+		}
+	}
+	if res.DataUsageUp != float64(len(nettests)) {
+		t.Fatalf("unexpected data usage: %f", res.DataUsageUp)
+	}
+	count, err := probe.DB().Collection("measurements").Find("result_id", res.ID).Count()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if count != uint64(len(nettests)) {
+		t.Fatalf("unexpected number of measurements: %d", count)
+	}
+}
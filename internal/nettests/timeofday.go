@@ -0,0 +1,31 @@
+package nettests
+
+import "time"
+
+// timeOfDayAnnotation is the annotation containing the local time of day
+// bucket in which we started running a nettest. Some censorship is only
+// enforced at specific times, so this allows to tell day and night apart
+// without revealing the precise local time.
+const timeOfDayAnnotation = "time_of_day"
+
+// Time of day buckets.
+const (
+	TimeOfDayNight     = "night"
+	TimeOfDayMorning   = "morning"
+	TimeOfDayAfternoon = "afternoon"
+	TimeOfDayEvening   = "evening"
+)
+
+// TimeOfDay returns the time of day bucket of t in t's location.
+func TimeOfDay(t time.Time) string {
+	switch hour := t.Hour(); {
+	case hour < 6:
+		return TimeOfDayNight
+	case hour < 12:
+		return TimeOfDayMorning
+	case hour < 18:
+		return TimeOfDayAfternoon
+	default:
+		return TimeOfDayEvening
+	}
+}
@@ -0,0 +1,24 @@
+package nettests
+
+import (
+	"testing"
+	"time"
+)
+
+func TestTimeOfDay(t *testing.T) {
+	loc := time.FixedZone("UTC+9", 9*60*60)
+	cases := map[int]string{
+		0:  TimeOfDayNight,
+		5:  TimeOfDayNight,
+		6:  TimeOfDayMorning,
+		12: TimeOfDayAfternoon,
+		18: TimeOfDayEvening,
+		23: TimeOfDayEvening,
+	}
+	for hour, expected := range cases {
+		when := time.Date(2020, 7, 1, hour, 30, 0, 0, loc)
+		if got := TimeOfDay(when); got != expected {
+			t.Fatalf("hour %d: expected %s, got %s", hour, expected, got)
+		}
+	}
+}
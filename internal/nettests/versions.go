@@ -0,0 +1,54 @@
+package nettests
+
+import (
+	"io/ioutil"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/apex/log"
+	"github.com/ooni/probe-cli/internal/utils"
+	"github.com/ooni/probe-engine/version"
+	"github.com/oschwald/maxminddb-golang"
+)
+
+// engineVersionAnnotation is the annotation containing the engine version.
+const engineVersionAnnotation = "engine_version"
+
+// versionAnnotations returns the annotations describing the client-side
+// logic that produced the measurements, i.e., the engine version and the
+// build date of each MaxMind database (e.g., the geoip databases), such that
+// analysts can account for client-side changes over time.
+func versionAnnotations(home string) (map[string]string, error) {
+	annotations := map[string]string{
+		engineVersionAnnotation: version.Version,
+	}
+	infos, err := ioutil.ReadDir(utils.AssetsDir(home))
+	if err != nil {
+		return annotations, err
+	}
+	for _, info := range infos {
+		if !info.Mode().IsRegular() || filepath.Ext(info.Name()) != ".mmdb" {
+			continue
+		}
+		epoch, err := mmdbBuildEpoch(filepath.Join(utils.AssetsDir(home), info.Name()))
+		if err != nil {
+			log.WithError(err).Debugf("cannot read the metadata of %s", info.Name())
+			continue
+		}
+		name := strings.TrimSuffix(info.Name(), filepath.Ext(info.Name()))
+		annotations["asset_"+name+"_date"] = time.Unix(int64(epoch), 0).UTC().Format("2006-01-02")
+	}
+	return annotations, nil
+}
+
+// mmdbBuildEpoch returns the time when the MaxMind database at the
+// given path was built, which is not when we downloaded it.
+func mmdbBuildEpoch(path string) (uint, error) {
+	reader, err := maxminddb.Open(path)
+	if err != nil {
+		return 0, err
+	}
+	defer reader.Close()
+	return reader.Metadata.BuildEpoch, nil
+}
@@ -0,0 +1,86 @@
+package nettests
+
+import (
+	"bytes"
+	"encoding/binary"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/ooni/probe-cli/internal/utils"
+)
+
+// mmdbWithBuildEpoch returns a MaxMind database without any network
+// whose metadata contains the given build epoch. See the MaxMind DB
+// file format specification for the encoding.
+func mmdbWithBuildEpoch(epoch uint64) []byte {
+	var buf bytes.Buffer
+	buf.Write(make([]byte, 16)) // data section separator
+	buf.WriteString("\xab\xcd\xefMaxMind.com")
+	writeString := func(s string) {
+		buf.WriteByte(0x40 | byte(len(s)))
+		buf.WriteString(s)
+	}
+	buf.WriteByte(0xE0 | 9) // map with nine entries
+	writeString("binary_format_major_version")
+	buf.Write([]byte{0xA1, 2}) // uint16
+	writeString("binary_format_minor_version")
+	buf.WriteByte(0xA0) // uint16 zero
+	writeString("build_epoch")
+	buf.Write([]byte{8, 9 - 7}) // uint64 is an extended type
+	binary.Write(&buf, binary.BigEndian, epoch)
+	writeString("database_type")
+	writeString("ooniprobe-test")
+	writeString("description")
+	buf.WriteByte(0xE0) // empty map
+	writeString("ip_version")
+	buf.Write([]byte{0xA1, 6})
+	writeString("languages")
+	buf.Write([]byte{0, 11 - 7}) // empty array, which is an extended type
+	writeString("node_count")
+	buf.WriteByte(0xC0) // uint32 zero
+	writeString("record_size")
+	buf.Write([]byte{0xA1, 24})
+	return buf.Bytes()
+}
+
+func TestVersionAnnotations(t *testing.T) {
+	home, err := ioutil.TempDir("", "ooniprobetests")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(home)
+	if err := os.MkdirAll(utils.AssetsDir(home), 0700); err != nil {
+		t.Fatal(err)
+	}
+	built := time.Date(2020, 7, 1, 12, 0, 0, 0, time.UTC)
+	asset := filepath.Join(utils.AssetsDir(home), "asn.mmdb")
+	if err := ioutil.WriteFile(asset, mmdbWithBuildEpoch(uint64(built.Unix())), 0600); err != nil {
+		t.Fatal(err)
+	}
+	// The modification time is when we downloaded the asset, which
+	// must not end up into the annotations.
+	downloaded := time.Date(2020, 11, 30, 12, 0, 0, 0, time.UTC)
+	if err := os.Chtimes(asset, downloaded, downloaded); err != nil {
+		t.Fatal(err)
+	}
+	invalid := filepath.Join(utils.AssetsDir(home), "country.mmdb")
+	if err := ioutil.WriteFile(invalid, []byte("antani"), 0600); err != nil {
+		t.Fatal(err)
+	}
+	annotations, err := versionAnnotations(home)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if annotations[engineVersionAnnotation] == "" {
+		t.Fatal("missing engine version")
+	}
+	if annotations["asset_asn_date"] != "2020-07-01" {
+		t.Fatalf("unexpected annotations: %+v", annotations)
+	}
+	if _, found := annotations["asset_country_date"]; found {
+		t.Fatal("unexpected annotation for an invalid database")
+	}
+}
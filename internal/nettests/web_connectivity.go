@@ -1,13 +1,25 @@
 package nettests
 
 import (
+	"bytes"
 	"context"
+	"fmt"
+	"io/ioutil"
+	"path/filepath"
+	"strconv"
 
 	"github.com/apex/log"
 	"github.com/ooni/probe-cli/internal/database"
+	"github.com/ooni/probe-cli/internal/i18n"
 	engine "github.com/ooni/probe-engine"
+	"github.com/ooni/probe-engine/model"
+	"github.com/pkg/errors"
 )
 
+// pinnedInputsAnnotation is the annotation containing the name of the
+// file inside the result directory that lists the measured inputs.
+const pinnedInputsAnnotation = "pinned_inputs_file"
+
 func lookupURLs(ctl *Controller, limit int64, categories []string) ([]string, map[int64]int64, error) {
 	inputloader := engine.NewInputLoader(engine.InputLoaderConfig{
 		InputPolicy:   engine.InputRequired,
@@ -23,11 +35,32 @@ func lookupURLs(ctl *Controller, limit int64, categories []string) ([]string, ma
 	if err != nil {
 		return nil, nil, err
 	}
+	testlist, skipped := ctl.doNotMeasureList().filter(testlist)
+	if skipped > 0 {
+		msg := i18n.New(
+			"nettests.do_not_measure_skipped", "count", strconv.Itoa(skipped))
+		log.WithField("message_id", msg.ID).Info(msg.String())
+	}
+	ctl.AddAnnotation(doNotMeasureAnnotation, strconv.Itoa(skipped))
+	if !ctl.Probe.Config().Advanced.AllowPrivateTargets {
+		testlist, skipped = filterPrivateTargets(testlist)
+		ctl.AddAnnotation(privateTargetAnnotation, strconv.Itoa(skipped))
+	}
+	if len(ctl.Inputs) <= 0 && len(ctl.InputFiles) <= 0 {
+		// The backend should only return URLs in the categories we
+		// have passed to the check-in, but since sensitive categories
+		// are opt-in we double check on our side as well.
+		testlist = filterByCategory(testlist, categories)
+	}
 	for idx, url := range testlist {
 		log.Debugf("Going over URL %d", idx)
-		urlID, err := database.CreateOrUpdateURL(
-			ctl.Probe.DB(), url.URL, url.CategoryCode, url.CountryCode,
-		)
+		var urlID int64
+		err := ctl.locked(func() (err error) {
+			urlID, err = database.CreateOrUpdateURL(
+				ctl.Probe.DB(), url.URL, url.CategoryCode, url.CountryCode,
+			)
+			return
+		})
 		if err != nil {
 			log.Error("failed to add to the URL table")
 			return nil, nil, err
@@ -36,20 +69,107 @@ func lookupURLs(ctl *Controller, limit int64, categories []string) ([]string, ma
 		urlIDMap[int64(idx)] = urlID
 		urls = append(urls, url.URL)
 	}
+	if err := pinInputs(ctl, "web_connectivity", urls); err != nil {
+		return nil, nil, err
+	}
 	return urls, urlIDMap, nil
 }
 
+// pinInputs writes the list of inputs we are about to measure into the
+// measurement directory of the result. Test lists change every day, so
+// passing this file to --input-file allows to measure again exactly the
+// same list, e.g., as part of a longitudinal study.
+func pinInputs(ctl *Controller, testName string, inputs []string) error {
+	var buf bytes.Buffer
+	for _, input := range inputs {
+		fmt.Fprintf(&buf, "%s\n", input)
+	}
+	filename := filepath.Join(ctl.res.MeasurementDir, fmt.Sprintf("inputs-%s.txt", testName))
+	if err := ioutil.WriteFile(filename, buf.Bytes(), 0600); err != nil {
+		return errors.Wrap(err, "failed to pin the list of inputs")
+	}
+	ctl.AddAnnotation(pinnedInputsAnnotation, filepath.Base(filename))
+	return nil
+}
+
+// filterByCategory only keeps the URLs whose category is enabled.
+func filterByCategory(in []model.URLInfo, categories []string) []model.URLInfo {
+	enabled := make(map[string]bool)
+	for _, c := range categories {
+		enabled[c] = true
+	}
+	var out []model.URLInfo
+	for _, info := range in {
+		if !enabled[info.CategoryCode] {
+			log.Debugf("Skipping %s because %s is not enabled", info.URL, info.CategoryCode)
+			continue
+		}
+		out = append(out, info)
+	}
+	return out
+}
+
+// repetitionsAnnotation is the annotation containing the number of times
+// we measure each URL within a run.
+const repetitionsAnnotation = "websites_repetitions"
+
+// repeatInputs returns the inputs repeated the given number of times along
+// with the corresponding input index to URL ID map. We repeat the whole list
+// rather than each URL, such that the measurements of the same URL are spaced
+// out in time, which is what we want to detect probabilistic blocking.
+func repeatInputs(urls []string, urlIDMap map[int64]int64, repetitions int) ([]string, map[int64]int64) {
+	if repetitions <= 1 {
+		return urls, urlIDMap
+	}
+	var out []string
+	outMap := make(map[int64]int64)
+	for r := 0; r < repetitions; r++ {
+		for idx, url := range urls {
+			outMap[int64(len(out))] = urlIDMap[int64(idx)]
+			out = append(out, url)
+		}
+	}
+	return out, outMap
+}
+
+// logBlockingProbabilities logs the fraction of anomalous measurements
+// of each URL that we have measured more than once.
+func logBlockingProbabilities(ctl *Controller) error {
+	var bps []database.BlockingProbability
+	err := ctl.locked(func() (err error) {
+		bps, err = database.ListBlockingProbabilities(
+			ctl.Probe.DB(), ctl.res.ID, "web_connectivity")
+		return
+	})
+	if err != nil {
+		return err
+	}
+	for _, bp := range bps {
+		log.WithFields(log.Fields{
+			"type":        "blocking_probability",
+			"url":         bp.URL,
+			"total":       bp.Total,
+			"anomalies":   bp.Anomalies,
+			"probability": bp.Probability(),
+		}).Infof("%s: %d/%d anomalous", bp.URL, bp.Anomalies, bp.Total)
+	}
+	return nil
+}
+
 // WebConnectivity test implementation
 type WebConnectivity struct {
 }
 
 // Run starts the test
 func (n WebConnectivity) Run(ctl *Controller) error {
-	log.Debugf("Enabled category codes are the following %v", ctl.Probe.Config().Nettests.WebsitesEnabledCategoryCodes)
-	urls, urlIDMap, err := lookupURLs(ctl, ctl.Probe.Config().Nettests.WebsitesURLLimit, ctl.Probe.Config().Nettests.WebsitesEnabledCategoryCodes)
+	categories := ctl.Probe.Config().EnabledCategoryCodes(ctl.Session.ProbeCC())
+	log.Debugf("Enabled category codes are the following %v", categories)
+	urls, urlIDMap, err := lookupURLs(ctl, ctl.Probe.Config().Nettests.WebsitesURLLimit, categories)
 	if err != nil {
 		return err
 	}
+	repetitions := ctl.Probe.Config().Nettests.WebsitesRepetitions
+	urls, urlIDMap = repeatInputs(urls, urlIDMap, repetitions)
 	ctl.SetInputIdxMap(urlIDMap)
 	builder, err := ctl.Session.NewExperimentBuilder(
 		"web_connectivity",
@@ -57,5 +177,12 @@ func (n WebConnectivity) Run(ctl *Controller) error {
 	if err != nil {
 		return err
 	}
-	return ctl.Run(builder, urls)
+	if repetitions <= 1 {
+		return ctl.Run(builder, urls)
+	}
+	ctl.AddAnnotation(repetitionsAnnotation, strconv.Itoa(repetitions))
+	if err := ctl.Run(builder, urls); err != nil {
+		return err
+	}
+	return logBlockingProbabilities(ctl)
 }
@@ -0,0 +1,58 @@
+package nettests
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/ooni/probe-cli/internal/database"
+)
+
+func TestRepeatInputs(t *testing.T) {
+	urls := []string{"https://a.example/", "https://b.example/"}
+	urlIDMap := map[int64]int64{0: 11, 1: 17}
+	out, outMap := repeatInputs(urls, urlIDMap, 3)
+	if len(out) != 6 || len(outMap) != 6 {
+		t.Fatal("unexpected number of inputs")
+	}
+	for idx, url := range out {
+		if url != urls[idx%2] {
+			t.Fatalf("unexpected input at %d: %s", idx, url)
+		}
+		if outMap[int64(idx)] != urlIDMap[int64(idx%2)] {
+			t.Fatalf("unexpected URL ID at %d", idx)
+		}
+	}
+}
+
+func TestRepeatInputsOnce(t *testing.T) {
+	urls := []string{"https://a.example/"}
+	out, _ := repeatInputs(urls, map[int64]int64{0: 11}, 0)
+	if len(out) != 1 {
+		t.Fatal("expected the inputs to be unchanged")
+	}
+}
+
+func TestPinInputs(t *testing.T) {
+	dir, err := ioutil.TempDir("", "ooniprobetests")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+	ctl := &Controller{res: &database.Result{MeasurementDir: dir}}
+	inputs := []string{"https://a.example/", "dns.example"}
+	if err := pinInputs(ctl, "dnscheck", inputs); err != nil {
+		t.Fatal(err)
+	}
+	if ctl.annotations[pinnedInputsAnnotation] != "inputs-dnscheck.txt" {
+		t.Fatalf("unexpected annotations: %+v", ctl.annotations)
+	}
+	data, err := ioutil.ReadFile(filepath.Join(dir, "inputs-dnscheck.txt"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if string(data) != "https://a.example/\ndns.example\n" {
+		t.Fatalf("unexpected pinned inputs: %s", data)
+	}
+}
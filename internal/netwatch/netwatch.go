@@ -0,0 +1,67 @@
+// Package netwatch detects when the probe moves to another network.
+package netwatch
+
+import (
+	"encoding/json"
+
+	"github.com/pkg/errors"
+)
+
+// lastLocationKey is the KVStore key containing the last known location.
+const lastLocationKey = "last_location.state"
+
+// KVStore is a generic key-value store. The engine's
+// FileSystemKVStore implements this interface.
+type KVStore interface {
+	Get(key string) ([]byte, error)
+	Set(key string, value []byte) error
+}
+
+// Location is the part of the probe location we care about.
+type Location struct {
+	ASN         string `json:"probe_asn"`
+	CountryCode string `json:"probe_cc"`
+}
+
+// Watcher compares the current location with the last known one.
+type Watcher struct {
+	kvstore KVStore
+}
+
+// NewWatcher creates a new Watcher using the given KVStore.
+func NewWatcher(kvstore KVStore) *Watcher {
+	return &Watcher{kvstore: kvstore}
+}
+
+// Last returns the last known location and whether there is one.
+func (w *Watcher) Last() (Location, bool, error) {
+	data, err := w.kvstore.Get(lastLocationKey)
+	if err != nil {
+		// The KVStore fails when the key does not exist, which
+		// means that this is the first time we look.
+		return Location{}, false, nil
+	}
+	var loc Location
+	if err := json.Unmarshal(data, &loc); err != nil {
+		return Location{}, false, errors.Wrap(err, "parsing last location")
+	}
+	return loc, true, nil
+}
+
+// Update stores the current location and returns the previous one along
+// with whether the location has changed. The first location we see is
+// not considered a change, since we do not know where we were before.
+func (w *Watcher) Update(cur Location) (Location, bool, error) {
+	prev, found, err := w.Last()
+	if err != nil {
+		return Location{}, false, err
+	}
+	data, err := json.Marshal(cur)
+	if err != nil {
+		return Location{}, false, errors.Wrap(err, "serializing location")
+	}
+	if err := w.kvstore.Set(lastLocationKey, data); err != nil {
+		return Location{}, false, errors.Wrap(err, "saving location")
+	}
+	return prev, found && prev != cur, nil
+}
@@ -0,0 +1,52 @@
+package netwatch
+
+import (
+	"errors"
+	"testing"
+)
+
+type memkvstore struct {
+	m map[string][]byte
+}
+
+func (kvs *memkvstore) Get(key string) ([]byte, error) {
+	value, found := kvs.m[key]
+	if !found {
+		return nil, errors.New("no such key")
+	}
+	return value, nil
+}
+
+func (kvs *memkvstore) Set(key string, value []byte) error {
+	kvs.m[key] = value
+	return nil
+}
+
+func TestWatcherUpdate(t *testing.T) {
+	w := NewWatcher(&memkvstore{m: make(map[string][]byte)})
+	home := Location{ASN: "AS30722", CountryCode: "IT"}
+	if _, changed, err := w.Update(home); err != nil || changed {
+		t.Fatal("the first location must not be a change")
+	}
+	if _, changed, err := w.Update(home); err != nil || changed {
+		t.Fatal("the same location must not be a change")
+	}
+	travel := Location{ASN: "AS3269", CountryCode: "IT"}
+	prev, changed, err := w.Update(travel)
+	if err != nil || !changed {
+		t.Fatal("expected a change")
+	}
+	if prev != home {
+		t.Fatalf("unexpected previous location: %+v", prev)
+	}
+	if last, found, err := w.Last(); err != nil || !found || last != travel {
+		t.Fatal("the location was not saved")
+	}
+}
+
+func TestWatcherCorruptedState(t *testing.T) {
+	kvs := &memkvstore{m: map[string][]byte{lastLocationKey: []byte("{")}}
+	if _, _, err := NewWatcher(kvs).Update(Location{}); err == nil {
+		t.Fatal("expected an error")
+	}
+}
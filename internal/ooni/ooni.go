@@ -4,6 +4,7 @@ import (
 	"io/ioutil"
 	"os"
 	"os/signal"
+	"sync"
 	"sync/atomic"
 	"syscall"
 
@@ -14,6 +15,7 @@ import (
 	"github.com/ooni/probe-cli/internal/enginex"
 	"github.com/ooni/probe-cli/internal/utils"
 	engine "github.com/ooni/probe-engine"
+	"github.com/ooni/probe-engine/model"
 	"github.com/pkg/errors"
 	"upper.io/db.v3/lib/sqlbuilder"
 )
@@ -56,6 +58,13 @@ type Probe struct {
 	// OSes as documented in https://golang.org/pkg/sync/atomic/#pkg-note-BUG.
 	isTerminatedAtomicInt int32
 
+	// terminated is closed when the probe is terminated, such that
+	// long waits can be interrupted.
+	terminated    chan struct{}
+	terminateOnce sync.Once
+
+	listenForSignalsOnce sync.Once
+
 	softwareName    string
 	softwareVersion string
 }
@@ -90,6 +99,16 @@ func (p *Probe) TempDir() string {
 	return p.tempDir
 }
 
+// SoftwareName returns the name of the application.
+func (p *Probe) SoftwareName() string {
+	return p.softwareName
+}
+
+// SoftwareVersion returns the version of the application.
+func (p *Probe) SoftwareVersion() string {
+	return p.softwareVersion
+}
+
 // IsTerminated checks to see if the isTerminatedAtomicInt is set to a non zero
 // value and therefore we have received the signal to shutdown the running test
 func (p *Probe) IsTerminated() bool {
@@ -100,22 +119,34 @@ func (p *Probe) IsTerminated() bool {
 // Terminate interrupts the running context
 func (p *Probe) Terminate() {
 	atomic.AddInt32(&p.isTerminatedAtomicInt, 1)
+	p.terminateOnce.Do(func() {
+		close(p.terminated)
+	})
+}
+
+// Terminated returns a channel that is closed when the probe is
+// terminated, which allows to interrupt long waits.
+func (p *Probe) Terminated() <-chan struct{} {
+	return p.terminated
 }
 
 // ListenForSignals will listen for SIGINT and SIGTERM. When it receives those
 // signals it will set isTerminatedAtomicInt to non-zero, which will cleanly
-// shutdown the test logic.
+// shutdown the test logic. Calling this function more than once
+// has no additional effect.
 //
 // TODO refactor this to use a cancellable context.Context instead of a bool
 // flag, probably as part of: https://github.com/ooni/probe-cli/issues/45
 func (p *Probe) ListenForSignals() {
-	s := make(chan os.Signal, 1)
-	signal.Notify(s, os.Interrupt, syscall.SIGINT, syscall.SIGTERM)
-	go func() {
-		<-s
-		log.Info("caught a stop signal, shutting down cleanly")
-		p.Terminate()
-	}()
+	p.listenForSignalsOnce.Do(func() {
+		s := make(chan os.Signal, 1)
+		signal.Notify(s, os.Interrupt, syscall.SIGINT, syscall.SIGTERM)
+		go func() {
+			<-s
+			log.Info("caught a stop signal, shutting down cleanly")
+			p.Terminate()
+		}()
+	})
 }
 
 // MaybeListenForStdinClosed will treat any error on stdin just
@@ -192,20 +223,44 @@ func (p *Probe) Init(softwareName, softwareVersion string) error {
 // current configuration inside the context. The caller must close
 // the session when done using it, by calling sess.Close().
 func (p *Probe) NewSession() (*engine.Session, error) {
+	kvstore, err := p.NewKVStore()
+	if err != nil {
+		return nil, err
+	}
+	return engine.NewSession(engine.SessionConfig{
+		AssetsDir:              utils.AssetsDir(p.home),
+		AvailableProbeServices: p.availableProbeServices(),
+		KVStore:                kvstore,
+		Logger:                 enginex.Logger,
+		SoftwareName:           p.softwareName,
+		SoftwareVersion:        p.softwareVersion,
+		TempDir:                p.tempDir,
+	})
+}
+
+// availableProbeServices returns the probe services configured by the
+// user or nil, in which case the engine uses its default services.
+func (p *Probe) availableProbeServices() []model.Service {
+	var out []model.Service
+	for _, svc := range p.config.Advanced.ProbeServices {
+		out = append(out, model.Service{
+			Address: svc.Address,
+			Type:    svc.Type,
+			Front:   svc.Front,
+		})
+	}
+	return out
+}
+
+// NewKVStore creates a new instance of the engine's key-value store.
+func (p *Probe) NewKVStore() (*engine.FileSystemKVStore, error) {
 	kvstore, err := engine.NewFileSystemKVStore(
 		utils.EngineDir(p.home),
 	)
 	if err != nil {
 		return nil, errors.Wrap(err, "creating engine's kvstore")
 	}
-	return engine.NewSession(engine.SessionConfig{
-		AssetsDir:       utils.AssetsDir(p.home),
-		KVStore:         kvstore,
-		Logger:          enginex.Logger,
-		SoftwareName:    p.softwareName,
-		SoftwareVersion: p.softwareVersion,
-		TempDir:         p.tempDir,
-	})
+	return kvstore, nil
 }
 
 // NewProbeEngine creates a new ProbeEngine instance.
@@ -224,6 +279,7 @@ func NewProbe(configPath string, homePath string) *Probe {
 		config:                &config.Config{},
 		configPath:            configPath,
 		isTerminatedAtomicInt: 0,
+		terminated:            make(chan struct{}),
 	}
 }
 
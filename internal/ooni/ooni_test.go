@@ -27,3 +27,18 @@ func TestInit(t *testing.T) {
 		t.Fatal("config file was not created")
 	}
 }
+
+func TestTerminated(t *testing.T) {
+	probe := NewProbe("", "")
+	select {
+	case <-probe.Terminated():
+		t.Fatal("terminated before calling Terminate")
+	default:
+	}
+	probe.Terminate()
+	probe.Terminate()
+	<-probe.Terminated()
+	if !probe.IsTerminated() {
+		t.Fatal("expected IsTerminated to be true")
+	}
+}
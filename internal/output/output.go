@@ -9,6 +9,7 @@ import (
 	"github.com/apex/log"
 	"github.com/ooni/probe-cli/internal/database"
 	"github.com/ooni/probe-cli/internal/utils"
+	"github.com/ooni/probe-cli/internal/verdict"
 )
 
 // MeasurementJSON prints the JSON of a measurement
@@ -58,6 +59,7 @@ func MeasurementSummary(msmt MeasurementSummaryData) {
 
 // MeasurementItem logs a progress type event
 func MeasurementItem(msmt database.MeasurementURLNetwork, isFirst bool, isLast bool) {
+	v := verdict.Explain(msmt.TestName, msmt.TestKeys, msmt.IsAnomaly.Bool)
 	log.WithFields(log.Fields{
 		"type":     "measurement_item",
 		"is_first": isFirst,
@@ -84,6 +86,10 @@ func MeasurementItem(msmt database.MeasurementURLNetwork, isFirst bool, isLast b
 		"is_done":               msmt.Measurement.IsDone,
 		"report_file_path":      msmt.ReportFilePath.String,
 		"measurement_file_path": msmt.MeasurementFilePath.String,
+		"verdict":               v.Message.String(),
+		"verdict_message_id":    v.Message.ID,
+		"verdict_params":        v.Message.Params,
+		"verdict_confidence":    v.Confidence,
 	}).Info("measurement")
 }
 
@@ -145,6 +151,22 @@ func ResultSummary(result ResultSummaryData) {
 	}).Info("result summary")
 }
 
+// UploadBacklogData describes the measurements we failed to submit.
+type UploadBacklogData struct {
+	Count  uint64
+	Oldest time.Time
+}
+
+// UploadBacklog logs the measurements we failed to submit, which allows
+// to detect problems reaching the collector.
+func UploadBacklog(backlog UploadBacklogData) {
+	log.WithFields(log.Fields{
+		"type":         "upload_backlog",
+		"count":        backlog.Count,
+		"oldest_start": backlog.Oldest,
+	}).Info("upload backlog")
+}
+
 // SectionTitle is the title of a section
 func SectionTitle(text string) {
 	log.WithFields(log.Fields{
@@ -36,6 +36,12 @@ func EngineDir(home string) string {
 	return filepath.Join(home, "engine")
 }
 
+// DescriptorsDir returns the directory containing the run descriptors
+// imported from run bundles given a specific OONI Home.
+func DescriptorsDir(home string) string {
+	return filepath.Join(home, "descriptors")
+}
+
 // DBDir returns the database dir for the given name
 func DBDir(home string, name string) string {
 	return filepath.Join(home, "db", fmt.Sprintf("%s.sqlite3", name))
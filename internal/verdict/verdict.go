@@ -0,0 +1,84 @@
+// Package verdict explains the result of a measurement in plain language.
+//
+// We map the summary of a measurement to a localizable i18n.Message and a
+// confidence level, such that frontends do not need to interpret the test
+// keys of each nettest to tell users what happened.
+package verdict
+
+import (
+	"encoding/json"
+
+	"github.com/ooni/probe-cli/internal/i18n"
+)
+
+// Confidence levels for Verdict.Confidence
+const (
+	ConfidenceLow    = "low"
+	ConfidenceMedium = "medium"
+	ConfidenceHigh   = "high"
+)
+
+// Verdict is the plain language explanation of a measurement.
+type Verdict struct {
+	Message    i18n.Message `json:"message"`
+	Confidence string       `json:"confidence"`
+}
+
+// instantMessagingApps maps the instant messaging nettests to the
+// name of the app that they measure.
+var instantMessagingApps = map[string]string{
+	"facebook_messenger": "Facebook Messenger",
+	"telegram":           "Telegram",
+	"whatsapp":           "WhatsApp",
+}
+
+// webConnectivityBlocking maps the web_connectivity blocking values to
+// the message ID and confidence. The HTTP diff heuristic is known to have
+// many false positives, hence the low confidence.
+var webConnectivityBlocking = map[string]Verdict{
+	"dns":          {i18n.New("verdict.web_connectivity.dns"), ConfidenceMedium},
+	"tcp_ip":       {i18n.New("verdict.web_connectivity.tcp_ip"), ConfidenceMedium},
+	"http-failure": {i18n.New("verdict.web_connectivity.http_failure"), ConfidenceMedium},
+	"http-diff":    {i18n.New("verdict.web_connectivity.http_diff"), ConfidenceLow},
+}
+
+// Explain returns the verdict of a measurement given the name of the
+// nettest, the summary test keys and whether it is anomalous.
+func Explain(testName string, testKeys string, isAnomaly bool) Verdict {
+	if testName == "web_connectivity" {
+		return explainWebConnectivity(testKeys, isAnomaly)
+	}
+	if app, found := instantMessagingApps[testName]; found {
+		if isAnomaly {
+			return Verdict{i18n.New("verdict.im.blocked", "app", app), ConfidenceMedium}
+		}
+		return Verdict{i18n.New("verdict.im.ok", "app", app), ConfidenceHigh}
+	}
+	if isAnomaly {
+		return Verdict{i18n.New("verdict.anomaly"), ConfidenceLow}
+	}
+	return Verdict{i18n.New("verdict.ok"), ConfidenceMedium}
+}
+
+func explainWebConnectivity(testKeys string, isAnomaly bool) Verdict {
+	var tk struct {
+		Accessible bool        `json:"accessible"`
+		Blocking   interface{} `json:"blocking"`
+	}
+	if err := json.Unmarshal([]byte(testKeys), &tk); err != nil {
+		return Verdict{i18n.New("verdict.unknown"), ConfidenceLow}
+	}
+	if blocking, ok := tk.Blocking.(string); ok {
+		if v, found := webConnectivityBlocking[blocking]; found {
+			return v
+		}
+	}
+	switch {
+	case isAnomaly:
+		return Verdict{i18n.New("verdict.anomaly"), ConfidenceLow}
+	case tk.Accessible:
+		return Verdict{i18n.New("verdict.web_connectivity.ok"), ConfidenceHigh}
+	default:
+		return Verdict{i18n.New("verdict.web_connectivity.down"), ConfidenceMedium}
+	}
+}
@@ -0,0 +1,52 @@
+package verdict
+
+import "testing"
+
+func TestExplainWebConnectivity(t *testing.T) {
+	var cases = []struct {
+		testKeys   string
+		isAnomaly  bool
+		id         string
+		confidence string
+	}{
+		{`{"accessible":true,"blocking":false}`, false, "verdict.web_connectivity.ok", ConfidenceHigh},
+		{`{"accessible":false,"blocking":false}`, false, "verdict.web_connectivity.down", ConfidenceMedium},
+		{`{"accessible":false,"blocking":"dns"}`, true, "verdict.web_connectivity.dns", ConfidenceMedium},
+		{`{"accessible":false,"blocking":"tcp_ip"}`, true, "verdict.web_connectivity.tcp_ip", ConfidenceMedium},
+		{`{"accessible":false,"blocking":"http-failure"}`, true, "verdict.web_connectivity.http_failure", ConfidenceMedium},
+		{`{"accessible":false,"blocking":"http-diff"}`, true, "verdict.web_connectivity.http_diff", ConfidenceLow},
+		{`{"accessible":null,"blocking":null}`, true, "verdict.anomaly", ConfidenceLow},
+		{`antani`, false, "verdict.unknown", ConfidenceLow},
+	}
+	for _, c := range cases {
+		v := Explain("web_connectivity", c.testKeys, c.isAnomaly)
+		if v.Message.ID != c.id || v.Confidence != c.confidence {
+			t.Fatalf("%s: unexpected verdict: %+v", c.testKeys, v)
+		}
+	}
+}
+
+func TestExplainInstantMessaging(t *testing.T) {
+	v := Explain("telegram", "{}", true)
+	if v.Message.ID != "verdict.im.blocked" || v.Confidence != ConfidenceMedium {
+		t.Fatalf("unexpected verdict: %+v", v)
+	}
+	if v.Message.String() != "Telegram is likely blocked on this network." {
+		t.Fatalf("unexpected string: %s", v.Message.String())
+	}
+	v = Explain("whatsapp", "{}", false)
+	if v.Message.ID != "verdict.im.ok" || v.Confidence != ConfidenceHigh {
+		t.Fatalf("unexpected verdict: %+v", v)
+	}
+}
+
+func TestExplainOtherNettests(t *testing.T) {
+	v := Explain("ndt", "{}", false)
+	if v.Message.ID != "verdict.ok" || v.Confidence != ConfidenceMedium {
+		t.Fatalf("unexpected verdict: %+v", v)
+	}
+	v = Explain("http_invalid_request_line", "{}", true)
+	if v.Message.ID != "verdict.anomaly" || v.Confidence != ConfidenceLow {
+		t.Fatalf("unexpected verdict: %+v", v)
+	}
+}